@@ -0,0 +1,133 @@
+package backtest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+const (
+	// FormatJSON - JSON array of archived alerts
+	FormatJSON = "json"
+	// FormatCSV - CSV with a Symbol,Price,CreatedAtUtc[,Profile,Direction] header
+	FormatCSV = "csv"
+	// FormatDynamoDB - DynamoDB "Export to S3" JSON lines, one {"Item":{...}} per line
+	FormatDynamoDB = "dynamodb"
+)
+
+// Alert - Archived alert as written by the archive lambda
+type Alert struct {
+	Symbol       string
+	Profile      string
+	Direction    string
+	Price        float64
+	CreatedAtUtc int64
+}
+
+// CreatedAt - Time the alert was sent
+func (a Alert) CreatedAt() time.Time {
+	return time.Unix(a.CreatedAtUtc, 0).UTC()
+}
+
+// ReadAlerts - Reads archived alerts in the given format
+func ReadAlerts(r io.Reader, format string) ([]Alert, error) {
+	switch format {
+	case FormatJSON:
+		var alerts []Alert
+		if err := json.NewDecoder(r).Decode(&alerts); err != nil {
+			return nil, err
+		}
+		return alerts, nil
+	case FormatCSV:
+		return readCSVAlerts(r)
+	case FormatDynamoDB:
+		return readDynamoDBAlerts(r)
+	}
+
+	return nil, fmt.Errorf("unknown alert format %q", format)
+}
+
+// readCSVAlerts - Reads alerts from CSV, columns are matched by header name
+func readCSVAlerts(r io.Reader) ([]Alert, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"Symbol", "Price", "CreatedAtUtc"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("alert csv is missing %s column", required)
+		}
+	}
+	get := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var alerts []Alert
+	for line, row := range rows[1:] {
+		price, err := strconv.ParseFloat(get(row, "Price"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("alert csv line %d: %w", line+2, err)
+		}
+		createdAt, err := strconv.ParseInt(get(row, "CreatedAtUtc"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("alert csv line %d: %w", line+2, err)
+		}
+
+		alerts = append(alerts, Alert{
+			Symbol:       get(row, "Symbol"),
+			Profile:      get(row, "Profile"),
+			Direction:    get(row, "Direction"),
+			Price:        price,
+			CreatedAtUtc: createdAt,
+		})
+	}
+
+	return alerts, nil
+}
+
+// readDynamoDBAlerts - Reads alerts from a DynamoDB JSON lines export
+func readDynamoDBAlerts(r io.Reader) ([]Alert, error) {
+	var alerts []Alert
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var item struct {
+			Item map[string]*dynamodb.AttributeValue
+		}
+		if err := json.Unmarshal([]byte(text), &item); err != nil {
+			return nil, fmt.Errorf("dynamodb export line %d: %w", line, err)
+		}
+
+		var a Alert
+		if err := dynamodbattribute.UnmarshalMap(item.Item, &a); err != nil {
+			return nil, fmt.Errorf("dynamodb export line %d: %w", line, err)
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, scanner.Err()
+}
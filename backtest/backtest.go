@@ -0,0 +1,177 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+// DefaultHorizons - Trading days after the alert that returns are measured at
+var DefaultHorizons = []int{1, 5, 20}
+
+// Horizon - Performance of a configuration's alerts a number of trading days after the alert
+type Horizon struct {
+	Days      int
+	Count     int     // alerts with enough history to measure
+	HitRate   float64 // fraction of alerts with a positive return
+	AvgReturn float64 // fractional, 0.1 = 10%
+}
+
+// Result - Performance of the alerts sent by a single screening configuration
+type Result struct {
+	Profile     string
+	Direction   string
+	Alerts      int
+	Skipped     int // alerts without an alert price or price history
+	Horizons    []Horizon
+	MaxDrawdown float64 // worst peak to trough decline from the alert price within the longest horizon, fractional
+}
+
+// Run - Joins the alerts with their daily closes and reports performance per profile and direction
+func Run(alerts []Alert, history market.PriceHistoryProvider, horizons []int) ([]Result, error) {
+	if len(horizons) == 0 {
+		horizons = DefaultHorizons
+	}
+	longest := 0
+	for _, h := range horizons {
+		if h < 1 {
+			return nil, fmt.Errorf("invalid horizon %d, must be a positive number of trading days", h)
+		}
+		if h > longest {
+			longest = h
+		}
+	}
+
+	type accumulator struct {
+		result  Result
+		hits    []int
+		returns []float64
+		counts  []int
+	}
+	groups := make(map[string]*accumulator)
+	var keys []string
+
+	for _, a := range alerts {
+		profile, direction := a.Profile, a.Direction
+		if profile == "" {
+			profile = "default"
+		}
+		if direction == "" {
+			direction = string(market.Gainers)
+		}
+
+		key := profile + "/" + direction
+		acc, ok := groups[key]
+		if !ok {
+			acc = &accumulator{
+				result: Result{
+					Profile:   profile,
+					Direction: direction,
+				},
+				hits:    make([]int, len(horizons)),
+				returns: make([]float64, len(horizons)),
+				counts:  make([]int, len(horizons)),
+			}
+			groups[key] = acc
+			keys = append(keys, key)
+		}
+		acc.result.Alerts++
+
+		if a.Price <= 0 {
+			log.Printf("%s alerted on %s has no alert price", a.Symbol, a.CreatedAt().Format("2006-01-02"))
+			acc.result.Skipped++
+			continue
+		}
+
+		closes, err := tradingDaysFrom(a, history, longest)
+		if errors.Is(err, market.ErrSymbolNotFound) {
+			log.Println(err) // e.g. delisted since the alert, log and continue with other alerts
+			acc.result.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(closes) == 0 {
+			log.Printf("%s has no price history after %s", a.Symbol, a.CreatedAt().Format("2006-01-02"))
+			acc.result.Skipped++
+			continue
+		}
+
+		for i, days := range horizons {
+			if days >= len(closes) {
+				continue
+			}
+
+			r := closes[days].Close/a.Price - 1
+			acc.counts[i]++
+			acc.returns[i] += r
+			if r > 0 {
+				acc.hits[i]++
+			}
+		}
+
+		if d := drawdown(a.Price, closes); d > acc.result.MaxDrawdown {
+			acc.result.MaxDrawdown = d
+		}
+	}
+
+	sort.Strings(keys)
+	var results []Result
+	for _, key := range keys {
+		acc := groups[key]
+		for i, days := range horizons {
+			h := Horizon{
+				Days:  days,
+				Count: acc.counts[i],
+			}
+			if h.Count > 0 {
+				h.HitRate = float64(acc.hits[i]) / float64(h.Count)
+				h.AvgReturn = acc.returns[i] / float64(h.Count)
+			}
+			acc.result.Horizons = append(acc.result.Horizons, h)
+		}
+		results = append(results, acc.result)
+	}
+
+	return results, nil
+}
+
+// tradingDaysFrom - Returns the alert day's close followed by up to days trading day closes
+func tradingDaysFrom(a Alert, history market.PriceHistoryProvider, days int) ([]market.DailyClose, error) {
+	year, month, day := a.CreatedAt().Date()
+	from := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// ~1.5 calendar days per trading day leaves room for weekends and holidays
+	to := from.AddDate(0, 0, days*3/2+7)
+
+	closes, err := history.GetDailyCloses(a.Symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(closes) > days+1 {
+		closes = closes[:days+1]
+	}
+
+	return closes, nil
+}
+
+// drawdown - Largest peak to trough decline starting from the alert price
+func drawdown(price float64, closes []market.DailyClose) float64 {
+	peak := price
+	var max float64
+	for _, c := range closes {
+		if c.Close > peak {
+			peak = c.Close
+		}
+		if d := 1 - c.Close/peak; d > max {
+			max = d
+		}
+	}
+
+	return max
+}
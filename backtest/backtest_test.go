@@ -0,0 +1,150 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+const history = `Symbol,Date,Close
+GNOG,2021-01-04,10
+GNOG,2021-01-05,11
+GNOG,2021-01-06,8
+GNOG,2021-01-07,12
+GNOG,2021-01-08,15
+MOON,2021-01-04,20
+MOON,2021-01-05,18
+MOON,2021-01-06,19
+`
+
+func unix(date string) int64 {
+	t, _ := time.Parse("2006-01-02", date)
+	return t.Add(15 * time.Hour).Unix()
+}
+
+func TestRun_Alerts_ReportsPerConfiguration(t *testing.T) {
+	h, err := NewCSVPriceHistory(strings.NewReader(history))
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	alerts := []Alert{
+		{Symbol: "GNOG", Price: 10, CreatedAtUtc: unix("2021-01-04")},
+		{Symbol: "MOON", Price: 20, CreatedAtUtc: unix("2021-01-04")},
+		{Symbol: "MOON", Profile: "moonshot", Direction: "losers", Price: 20, CreatedAtUtc: unix("2021-01-04")},
+		{Symbol: "NONE", Price: 5, CreatedAtUtc: unix("2021-01-04")},
+	}
+
+	results, err := Run(alerts, h, []int{1, 3})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Failed with unexpected response: %v", results)
+	}
+
+	r := results[0]
+	if r.Profile != "default" || r.Direction != "gainers" || r.Alerts != 3 || r.Skipped != 1 {
+		t.Fatalf("Failed with unexpected default result: %+v", r)
+	}
+	// day 1: GNOG +10%, MOON -10%
+	if r.Horizons[0].Count != 2 || r.Horizons[0].HitRate != 0.5 || math.Abs(r.Horizons[0].AvgReturn) > 0.0001 {
+		t.Fatalf("Failed with unexpected day 1 horizon: %+v", r.Horizons[0])
+	}
+	// day 3: only GNOG has history, +20%
+	if r.Horizons[1].Count != 1 || r.Horizons[1].HitRate != 1 || math.Abs(r.Horizons[1].AvgReturn-0.2) > 0.0001 {
+		t.Fatalf("Failed with unexpected day 3 horizon: %+v", r.Horizons[1])
+	}
+	// GNOG peaked at 11 then fell to 8
+	if math.Abs(r.MaxDrawdown-(1-8.0/11)) > 0.0001 {
+		t.Fatalf("Failed with unexpected max drawdown: %.4f", r.MaxDrawdown)
+	}
+
+	if results[1].Profile != "moonshot" || results[1].Direction != "losers" || results[1].Alerts != 1 {
+		t.Fatalf("Failed with unexpected moonshot result: %+v", results[1])
+	}
+}
+
+// unavailableHistory - PriceHistoryProvider failing with the error for every symbol but GNOG
+type unavailableHistory struct {
+	market.PriceHistoryProvider
+	err error
+}
+
+func (h unavailableHistory) GetDailyCloses(symbol string, from time.Time, to time.Time) ([]market.DailyClose, error) {
+	if symbol == "GNOG" {
+		return h.PriceHistoryProvider.GetDailyCloses(symbol, from, to)
+	}
+
+	return nil, fmt.Errorf("%s: %w", symbol, h.err)
+}
+
+func TestRun_HistoryErrors_SkipsUnknownSymbols(t *testing.T) {
+	h, err := NewCSVPriceHistory(strings.NewReader(history))
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	alerts := []Alert{
+		{Symbol: "GNOG", Price: 10, CreatedAtUtc: unix("2021-01-04")},
+		{Symbol: "GONE", Price: 5, CreatedAtUtc: unix("2021-01-04")},
+		{Symbol: "FREE", CreatedAtUtc: unix("2021-01-04")},
+	}
+
+	results, err := Run(alerts, unavailableHistory{h, market.ErrSymbolNotFound}, []int{1})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(results) != 1 || results[0].Alerts != 3 || results[0].Skipped != 2 || results[0].Horizons[0].Count != 1 {
+		t.Fatalf("Failed with unexpected response: %+v", results)
+	}
+
+	if _, err = Run(alerts, unavailableHistory{h, market.ErrRateLimited}, []int{1}); !errors.Is(err, market.ErrRateLimited) {
+		t.Fatalf("Failed with unexpected error: %v", err)
+	}
+}
+
+func TestRun_NonPositiveHorizon_ReturnsError(t *testing.T) {
+	h, err := NewCSVPriceHistory(strings.NewReader(history))
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	alerts := []Alert{{Symbol: "GNOG", Price: 10, CreatedAtUtc: unix("2021-01-04")}}
+
+	for _, horizons := range [][]int{{0}, {1, -5}} {
+		if _, err := Run(alerts, h, horizons); err == nil {
+			t.Fatalf("Failed with unexpected success for horizons %v", horizons)
+		}
+	}
+}
+
+func TestReadAlerts_Formats_ReturnsAlerts(t *testing.T) {
+	tcs := []struct {
+		format string
+		body   string
+	}{
+		{FormatJSON, `[{"Symbol":"GNOG","Profile":"moonshot","Price":10.5,"CreatedAtUtc":1609772400}]`},
+		{FormatCSV, "Symbol,Price,CreatedAtUtc,Profile\nGNOG,10.5,1609772400,moonshot\n"},
+		{FormatDynamoDB, `{"Item":{"Symbol":{"S":"GNOG"},"Profile":{"S":"moonshot"},"Price":{"N":"10.5"},"CreatedAtUtc":{"N":"1609772400"}}}` + "\n"},
+	}
+
+	for _, tc := range tcs {
+		alerts, err := ReadAlerts(strings.NewReader(tc.body), tc.format)
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", tc.format, err)
+		}
+
+		expected := Alert{Symbol: "GNOG", Profile: "moonshot", Price: 10.5, CreatedAtUtc: 1609772400}
+		if len(alerts) != 1 || alerts[0] != expected {
+			t.Fatalf("%s failed with unexpected response: %+v", tc.format, alerts)
+		}
+	}
+
+	if _, err := ReadAlerts(strings.NewReader(""), "xml"); err == nil {
+		t.Fatal("Failed to reject unknown format")
+	}
+}
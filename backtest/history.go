@@ -0,0 +1,73 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+type csvPriceHistory struct {
+	closes map[string][]market.DailyClose
+}
+
+// NewCSVPriceHistory - Returns a PriceHistoryProvider backed by a Symbol,Date,Close CSV with YYYY-MM-DD dates
+func NewCSVPriceHistory(r io.Reader) (market.PriceHistoryProvider, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &csvPriceHistory{
+		closes: make(map[string][]market.DailyClose),
+	}
+	for line, row := range rows {
+		if len(row) < 3 {
+			return nil, fmt.Errorf("price history csv line %d: expected Symbol,Date,Close", line+1)
+		}
+		if line == 0 && strings.EqualFold(strings.TrimSpace(row[0]), "symbol") {
+			continue // header
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(row[1]))
+		if err != nil {
+			return nil, fmt.Errorf("price history csv line %d: %w", line+1, err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("price history csv line %d: %w", line+1, err)
+		}
+
+		symbol := strings.TrimSpace(row[0])
+		h.closes[symbol] = append(h.closes[symbol], market.DailyClose{
+			Date:  date,
+			Close: price,
+		})
+	}
+
+	for _, closes := range h.closes {
+		sort.Slice(closes, func(i, j int) bool {
+			return closes[i].Date.Before(closes[j].Date)
+		})
+	}
+
+	return h, nil
+}
+
+// GetDailyCloses - Implementation of the PriceHistoryProvider interface
+func (h *csvPriceHistory) GetDailyCloses(symbol string, from time.Time, to time.Time) ([]market.DailyClose, error) {
+	var closes []market.DailyClose
+	for _, c := range h.closes[symbol] {
+		if c.Date.Before(from) || c.Date.After(to) {
+			continue
+		}
+		closes = append(closes, c)
+	}
+
+	return closes, nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/scan"
+)
+
+const usage = `Runs the stonk-lambda scan pipeline locally using the same environment variables as the lambda.
+
+Usage:
+  stonk scan [--losers] [--dry-run] [--output table|json]
+  stonk analyze [--losers] [--output table|json] SYMBOL...
+  stonk providers [--losers]
+  stonk digest [--since RFC3339] [--dry-run] [--output table|json]
+  stonk watch [--since RFC3339] [--dry-run] [--output table|json]
+`
+
+// options - Flags shared by the subcommands
+type options struct {
+	losers bool
+	dryRun bool
+	output string
+	since  string
+}
+
+func (o options) direction() market.Direction {
+	if o.losers {
+		return market.Losers
+	}
+
+	return market.Gainers
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var o options
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	fs.BoolVar(&o.losers, "losers", false, "screen top losers instead of gainers")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "skip data store writes and notifications")
+	fs.StringVar(&o.output, "output", "table", "output format: table or json")
+	fs.StringVar(&o.since, "since", "", "digest or watch start, defaults to the start of the UTC day")
+	fs.Parse(os.Args[2:])
+
+	if o.output != "table" && o.output != "json" {
+		log.Fatalf("unknown output %q", o.output)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "scan":
+		err = runScan(o)
+	case "analyze":
+		err = runAnalyze(o, fs.Args())
+	case "providers":
+		err = runProviders(o)
+	case "digest":
+		err = runDigest(o)
+	case "watch":
+		err = runWatch(o)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if degraded := market.DegradedProviders(); len(degraded) > 0 {
+		fmt.Fprintln(os.Stderr, market.FormatDegraded(degraded))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runScan - Runs the full pipeline: top movers, screening, dedupe and notification
+func runScan(o options) error {
+	profiles, err := scan.ProfilesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	store, err := scan.StoreFromEnv()
+	if err != nil {
+		return err
+	}
+
+	candidates, err := scan.CandidateStoreFromEnv()
+	if err != nil {
+		return err
+	}
+
+	queue, err := scan.QueueFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if err = trackKeys(o); err != nil {
+		return err
+	}
+
+	s := scan.New(profiles, store)
+	s.Candidates = candidates
+	s.Queue = queue
+	s.DryRun = o.dryRun
+	result, err := s.Run(o.direction())
+	if err != nil {
+		return err
+	}
+
+	return printResult(o, result)
+}
+
+// trackKeys - Shares the upstream API key usage through the key store, dry runs keep it in process memory since
+// they skip data store writes
+func trackKeys(o options) error {
+	if o.dryRun {
+		return nil
+	}
+
+	keys, err := scan.KeyStoreFromEnv()
+	if err != nil {
+		return err
+	}
+	market.SetKeyStore(keys)
+
+	return nil
+}
+
+// runAnalyze - Screens the symbols against every profile without dedupe, writes or notifications
+func runAnalyze(o options, symbols []string) error {
+	if len(symbols) == 0 {
+		return fmt.Errorf("analyze requires at least one SYMBOL")
+	}
+
+	profiles, err := scan.ProfilesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	s := scan.New(profiles, nil)
+	s.DryRun = true
+	for i := range symbols {
+		symbols[i] = strings.ToUpper(symbols[i])
+	}
+
+	return printResult(o, s.Screen(o.direction(), symbols))
+}
+
+// runProviders - Lists the top movers returned by each provider
+func runProviders(o options) error {
+	if err := trackKeys(o); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tSYMBOLS")
+	for _, p := range market.GetTopMoversProviders() {
+		symbols, err := p.GetTopMovers(o.direction())
+		if err != nil {
+			fmt.Fprintf(w, "%s\terror: %s\n", p.Name(), err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", p.Name(), strings.Join(symbols, " "))
+	}
+
+	return w.Flush()
+}
+
+func (o options) sinceTime() (time.Time, error) {
+	if o.since == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+
+	return time.Parse(time.RFC3339, o.since)
+}
+
+// runWatch - Sends follow-ups for the stocks alerted since the start of the day that reached a price target or
+// their stop-loss
+func runWatch(o options) error {
+	since, err := o.sinceTime()
+	if err != nil {
+		return err
+	}
+
+	profiles, err := scan.ProfilesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	store, err := scan.StoreFromEnv()
+	if err != nil {
+		return err
+	}
+
+	queue, err := scan.QueueFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if err = trackKeys(o); err != nil {
+		return err
+	}
+
+	s := scan.New(profiles, store)
+	s.Queue = queue
+	s.DryRun = o.dryRun
+	result, err := s.Watch(since)
+	if err != nil {
+		return err
+	}
+
+	return printResult(o, result)
+}
+
+// runDigest - Summarizes the candidates recorded since the start of the day and sends each profile its digest
+func runDigest(o options) error {
+	since, err := o.sinceTime()
+	if err != nil {
+		return err
+	}
+
+	profiles, err := scan.ProfilesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	candidates, err := scan.CandidateStoreFromEnv()
+	if err != nil {
+		return err
+	}
+
+	queue, err := scan.QueueFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if err = trackKeys(o); err != nil {
+		return err
+	}
+
+	s := scan.New(profiles, nil)
+	s.Candidates = candidates
+	s.Queue = queue
+	s.DryRun = o.dryRun
+	digest, err := s.Digest(since)
+	if err != nil {
+		return err
+	}
+
+	if o.output == "json" {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(digest)
+	}
+
+	_, err = fmt.Print(digest.Format())
+	return err
+}
+
+// printResult - Writes the accepted and rejected symbols as tables or JSON
+func printResult(o options, result scan.Result) error {
+	if o.output == "json" {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(result)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tSYMBOL\tSCORE\tGAIN\tPRICE\tTARGET MEAN\tSB/B/H/S/SS\tNEWS")
+	var profiles []string
+	for profile := range result.Alerts {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		for _, s := range result.Alerts[profile] {
+			fmt.Fprintf(w, "%s\t%s\t%.1f\t%.2f%%\t%.2f\t%.2f\t%d/%d/%d/%d/%d\t%s\n",
+				profile,
+				s.Symbol,
+				s.Score,
+				s.Gain,
+				s.CurrentPrice,
+				s.TargetMeanPrice,
+				s.StrongBuy,
+				s.Buy,
+				s.Hold,
+				s.Sell,
+				s.StrongSell,
+				s.NewsURL)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "SYMBOL\tPROFILE\tGATE\tREASON")
+	for _, r := range result.Rejections {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Symbol, r.Profile, r.Gate, r.Reason)
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "ERROR\tCOUNT")
+		var kinds []string
+		for kind := range result.Errors {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(w, "%s\t%d\n", kind, result.Errors[kind])
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// candidateRetention - How long candidates are kept for digests
+const candidateRetention = 7 * 24 * time.Hour
+
+// Candidate - Top mover screened by a scan run for a profile, alerted or rejected by a gate
+type Candidate struct {
+	Key             string // record key, RunID#Symbol#Profile#Direction
+	RunID           string
+	Symbol          string
+	Profile         string `dynamodbav:",omitempty" json:",omitempty"`
+	Direction       string
+	Alerted         bool
+	Gate            string `dynamodbav:",omitempty" json:",omitempty"` // rule or step that rejected the candidate
+	Reason          string `dynamodbav:",omitempty" json:",omitempty"`
+	Gain            float64
+	Price           float64
+	TargetMeanPrice float64
+	TargetHighPrice float64
+	Score           float64
+	CreatedAtUtc    int64
+	TTL             int64
+}
+
+// CandidateStore - Candidates seen by each scan run, kept for end-of-day digests
+type CandidateStore interface {
+	// InsertCandidates - Inserts the candidates of a run
+	InsertCandidates(candidates []Candidate) error
+	// Candidates - Returns the candidates seen since the time, oldest first
+	Candidates(since time.Time) ([]Candidate, error)
+}
+
+// OpenCandidates - Returns the CandidateStore of the kind, target is the table name for dynamodb and the path for
+// file
+func OpenCandidates(kind string, target string) (CandidateStore, error) {
+	if err := checkKind("candidate store", kind, target); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case KindDynamoDB:
+		return NewDynamoDBCandidates(target), nil
+	case KindFile:
+		return NewFileCandidates(target), nil
+	}
+
+	return NewMemoryCandidates(), nil
+}
+
+// newCandidate - Returns the candidate with its key, creation time and TTL set for insertion
+func newCandidate(c Candidate) Candidate {
+	t := now()
+	c.Symbol = strings.ToUpper(strings.TrimSpace(c.Symbol))
+	c.Key = strings.Join([]string{c.RunID, c.Symbol, c.Profile, c.Direction}, keySeparator)
+	c.CreatedAtUtc = t.Unix()
+	c.TTL = t.Add(candidateRetention).Unix()
+
+	return c
+}
+
+// recentCandidates - Returns the candidates created since the time, oldest first
+func recentCandidates(candidates []Candidate, since time.Time) []Candidate {
+	var result []Candidate
+	for _, c := range candidates {
+		if c.CreatedAtUtc >= since.UTC().Unix() {
+			result = append(result, c)
+		}
+	}
+
+	sortCandidates(result)
+
+	return result
+}
+
+func sortCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].CreatedAtUtc == candidates[j].CreatedAtUtc {
+			return candidates[i].Key < candidates[j].Key
+		}
+		return candidates[i].CreatedAtUtc < candidates[j].CreatedAtUtc
+	})
+}
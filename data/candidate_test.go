@@ -0,0 +1,67 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCandidateStore_Candidates_ReturnsCandidatesSince(t *testing.T) {
+	dir, err := ioutil.TempDir("", "data")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	stores := map[string]CandidateStore{
+		KindMemory: NewMemoryCandidates(),
+		KindFile:   NewFileCandidates(filepath.Join(dir, "candidates.json")),
+	}
+
+	start := time.Date(2021, 1, 4, 14, 30, 0, 0, time.UTC)
+	for kind, s := range stores {
+		restore := setNow(start)
+		err := s.InsertCandidates([]Candidate{{RunID: "run1", Symbol: " gnog", Profile: "moonshot", Direction: "gainers", Gate: "gainThreshold"}})
+		restore()
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+
+		restore = setNow(start.Add(time.Hour))
+		err = s.InsertCandidates([]Candidate{
+			{RunID: "run2", Symbol: "MOON", Profile: "moonshot", Direction: "gainers", Alerted: true, Gain: 55},
+			{RunID: "run2", Symbol: "GNOG", Profile: "moonshot", Direction: "gainers", Gate: "noSellRating"},
+		})
+		restore()
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+
+		all, err := s.Candidates(start)
+		if err != nil || len(all) != 3 || all[0].Key != "run1#GNOG#moonshot#gainers" || all[0].Symbol != "GNOG" {
+			t.Fatalf("%s failed with unexpected candidates: %v %v", kind, all, err)
+		}
+		if all[0].TTL != start.Add(candidateRetention).Unix() {
+			t.Fatalf("%s failed with unexpected TTL: %d", kind, all[0].TTL)
+		}
+
+		later, err := s.Candidates(start.Add(time.Minute))
+		if err != nil || len(later) != 2 || later[0].Symbol != "GNOG" || !later[1].Alerted {
+			t.Fatalf("%s failed with unexpected candidates: %v %v", kind, later, err)
+		}
+	}
+}
+
+func TestOpenCandidates(t *testing.T) {
+	if _, err := OpenCandidates(KindMemory, ""); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if _, err := OpenCandidates(KindFile, ""); err == nil {
+		t.Fatal("Failed to reject file candidate store without a path")
+	}
+	if _, err := OpenCandidates("bolt", "x"); err == nil {
+		t.Fatal("Failed to reject unknown candidate store")
+	}
+}
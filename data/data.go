@@ -2,105 +2,182 @@ package data
 
 import (
 	"fmt"
-	"log"
+	"strings"
 	"time"
+)
+
+const (
+	// DefaultProfile - Screening profile whose records are keyed by the bare symbol
+	DefaultProfile = "default"
+	// DefaultDirection - Top movers direction whose records are keyed by the bare symbol
+	DefaultDirection = "gainers"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	keySeparator = "#"
 )
 
-type data struct {
-	TableName string
+// ReAlertPolicy - Increase over the last alerted gain or price that allows a follow-up alert for a key that was
+// already alerted, zero disables the step
+type ReAlertPolicy struct {
+	GainStep  float64 // percentage points above the last alerted gain
+	PriceStep float64 // percent above the last alerted price
 }
 
-// New - Public constructor for data
-func New(tableName string) *data {
-	if tableName == "" {
-		log.Panic("tableName cannot be empty")
+// allows - Determines if the next alert has increased enough over the previous one
+func (p ReAlertPolicy) allows(previous Record, next Record) bool {
+	if p.GainStep > 0 && next.Percentage >= previous.Percentage+p.GainStep {
+		return true
 	}
-
-	return &data{
-		TableName: tableName,
+	if p.PriceStep > 0 && previous.Price > 0 && next.Price >= previous.Price*(1+p.PriceStep/100) {
+		return true
 	}
+
+	return false
 }
 
-// Exists - Determines if a record for the symbol exists in the data store
-func (d *data) Exists(symbol string) (bool, error) {
-	svc := dynamodb.New(session.New())
-	input := &dynamodb.GetItemInput{
-		Key: map[string]*dynamodb.AttributeValue{
-			"Symbol": {
-				S: aws.String(symbol),
-			},
-		},
-		TableName: aws.String(d.TableName),
-	}
+// ClaimResult - Outcome of claiming an alert
+type ClaimResult struct {
+	Won      bool
+	Update   bool   // the key was already alerted and the re-alert policy allowed a follow-up
+	Previous Record // last alert for the key when Update
+}
 
-	result, err := svc.GetItem(input)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case dynamodb.ErrCodeProvisionedThroughputExceededException:
-				log.Print(dynamodb.ErrCodeProvisionedThroughputExceededException, aerr.Error())
-			case dynamodb.ErrCodeResourceNotFoundException:
-				log.Print(dynamodb.ErrCodeResourceNotFoundException, aerr.Error())
-			case dynamodb.ErrCodeRequestLimitExceeded:
-				log.Print(dynamodb.ErrCodeRequestLimitExceeded, aerr.Error())
-			case dynamodb.ErrCodeInternalServerError:
-				log.Print(dynamodb.ErrCodeInternalServerError, aerr.Error())
-			default:
-				log.Print(aerr.Error())
-			}
-		} else {
-			log.Print(err.Error())
-		}
+// claim - Claims the alert against the existing record for its key
+func claim(existing Record, exists bool, next Record, policy ReAlertPolicy) ClaimResult {
+	if !exists || existing.expired() {
+		return ClaimResult{Won: true}
 	}
-	if result.Item == nil {
-		return false, err
+	if policy.allows(existing, next) {
+		return ClaimResult{Won: true, Update: true, Previous: existing}
+	}
+
+	return ClaimResult{}
+}
+
+// Record - Stock alerted for a screening profile and top movers direction
+type Record struct {
+	Symbol       string // record key, see Key
+	Ticker       string
+	Profile      string
+	Direction    string
+	Percentage   float64 // last alerted gain
+	Price        float64 // last alerted price
+	AlertPrice   float64 `dynamodbav:",omitempty" json:",omitempty"` // first alerted price, kept by re-alerts
+	Event        string  `dynamodbav:",omitempty" json:",omitempty"` // follow-up event of an earlier alert, see EventKey
+	CreatedAtUtc int64
+	TTL          int64
+}
+
+// Store - Short-lived cache of alerted stocks used to de-duplicate alerts
+type Store interface {
+	// Exists - Determines if an unexpired record for the key exists in the data store
+	Exists(key string) (bool, error)
+	// Insert - Inserts the alerted stock, keyed by Key
+	Insert(r Record) error
+	// Claim - Atomically inserts the alerted stock unless an unexpired record for its key exists that the
+	// re-alert policy doesn't allow a follow-up for, and returns whether this call won the right to notify
+	Claim(r Record, policy ReAlertPolicy) (ClaimResult, error)
+	// Recent - Returns the records created since the time
+	Recent(since time.Time) ([]Record, error)
+}
+
+const (
+	// KindDynamoDB - Store backed by a DynamoDB table
+	KindDynamoDB = "dynamodb"
+	// KindMemory - Store held in process memory
+	KindMemory = "memory"
+	// KindFile - Store backed by a local JSON file
+	KindFile = "file"
+)
+
+// Open - Returns the Store of the kind, target is the table name for dynamodb and the path for file
+func Open(kind string, target string) (Store, error) {
+	if err := checkKind("store", kind, target); err != nil {
+		return nil, err
 	}
 
-	if *result.Item["Symbol"].S == symbol {
-		return true, nil
+	switch kind {
+	case KindDynamoDB:
+		return New(target), nil
+	case KindFile:
+		return NewFile(target), nil
 	}
-	return false, fmt.Errorf("Symbol %s returned wrong record %v", symbol, result.Item)
+
+	return NewMemory(), nil
 }
 
-// Insert - Inserts the stock into the short-lived cache data store
-func (d *data) Insert(symbol string, percentage float64, price float64) error {
-	item := struct {
-		Symbol       string
-		Percentage   float64
-		Price        float64
-		CreatedAtUtc int64
-		TTL          int64
-	}{
-		Symbol:       symbol,
-		Percentage:   percentage,
-		Price:        price,
-		CreatedAtUtc: time.Now().UTC().Unix(),
-		TTL:          getItemTTL(time.Now().UTC()),
+// checkKind - Validates the kind of a store and its target, the table name for dynamodb and the path for file.
+// name describes the store in errors.
+func checkKind(name string, kind string, target string) error {
+	switch kind {
+	case KindDynamoDB:
+		if target == "" {
+			return fmt.Errorf("dynamodb %s requires a table name", name)
+		}
+	case KindMemory:
+	case KindFile:
+		if target == "" {
+			return fmt.Errorf("file %s requires a path", name)
+		}
+	default:
+		return fmt.Errorf("unknown data store %q", kind)
 	}
 
-	svc := dynamodb.New(session.New())
+	return nil
+}
 
-	av, err := dynamodbattribute.MarshalMap(item)
-	if err != nil {
-		return err
+// now - Current time, replaced in tests
+var now = func() time.Time {
+	return time.Now().UTC()
+}
+
+// newRecord - Returns the record with its key, creation time and TTL set for insertion
+func newRecord(r Record) Record {
+	t := now()
+	r.Ticker = strings.ToUpper(strings.TrimSpace(r.Ticker))
+	r.Symbol = Key(r.Ticker, r.Profile, r.Direction)
+	if r.Event != "" {
+		r.Symbol = EventKey(r.Symbol, r.Event)
+	}
+	if r.AlertPrice == 0 {
+		r.AlertPrice = r.Price
 	}
+	r.CreatedAtUtc = t.Unix()
+	r.TTL = getItemTTL(t)
+
+	return r
+}
 
-	input := &dynamodb.PutItemInput{
-		Item:      av,
-		TableName: aws.String(d.TableName),
+// firstAlertPrice - Returns the price of the key's first alert, records inserted before AlertPrice was kept only
+// have their last alerted price
+func firstAlertPrice(r Record) float64 {
+	if r.AlertPrice > 0 {
+		return r.AlertPrice
 	}
 
-	if _, err := svc.PutItem(input); err != nil {
-		return err
+	return r.Price
+}
+
+// expired - Determines if the record's TTL has passed
+func (r Record) expired() bool {
+	return r.TTL != 0 && r.TTL <= now().Unix()
+}
+
+// Key - Returns the record key for the symbol, qualified by the non-default screening profile and direction
+func Key(symbol string, profile string, direction string) string {
+	key := strings.ToUpper(strings.TrimSpace(symbol))
+	if profile != "" && profile != DefaultProfile {
+		key += keySeparator + profile
+	}
+	if direction != "" && direction != DefaultDirection {
+		key += keySeparator + direction
 	}
 
-	return nil
+	return key
+}
+
+// EventKey - Returns the record key for a follow-up event of the alert with the key
+func EventKey(key string, event string) string {
+	return key + keySeparator + event
 }
 
 // getItemTTL - Returns the epoch value for 2am tomorrow in UTC
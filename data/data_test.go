@@ -14,3 +14,29 @@ func TestGetItemTTL(t *testing.T) {
 		t.Fatalf("Expected ttl:%d to be after now:%d", ttl, now.Unix())
 	}
 }
+
+func TestKey(t *testing.T) {
+	tcs := []struct {
+		symbol    string
+		profile   string
+		direction string
+		expected  string
+	}{
+		{"GNOG", "", "", "GNOG"},
+		{"GNOG", DefaultProfile, DefaultDirection, "GNOG"},
+		{"GNOG", "moonshot", "", "GNOG#moonshot"},
+		{"GNOG", DefaultProfile, "losers", "GNOG#losers"},
+		{"GNOG", "moonshot", "losers", "GNOG#moonshot#losers"},
+		{" gnog", "", "", "GNOG"},
+	}
+
+	for _, tc := range tcs {
+		if actual := Key(tc.symbol, tc.profile, tc.direction); actual != tc.expected {
+			t.Fatalf("Failed expected:%s actual:%s", tc.expected, actual)
+		}
+	}
+
+	if actual := EventKey(Key("GNOG", "moonshot", ""), "stopLoss"); actual != "GNOG#moonshot#stopLoss" {
+		t.Fatalf("Failed with unexpected event key: %s", actual)
+	}
+}
@@ -0,0 +1,477 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// table - DynamoDB table backing one of the stores
+type table struct {
+	TableName string
+	svc       *dynamodb.DynamoDB
+}
+
+// newTable - Returns the table with a client from the default AWS session
+func newTable(tableName string) table {
+	if tableName == "" {
+		log.Panic("tableName cannot be empty")
+	}
+
+	return table{
+		TableName: tableName,
+		svc:       dynamodb.New(session.New()),
+	}
+}
+
+type data struct {
+	table
+}
+
+// New - Public constructor for the DynamoDB Store
+func New(tableName string) *data {
+	return &data{newTable(tableName)}
+}
+
+// Exists - Determines if an unexpired record for the key exists in the data store
+func (d *data) Exists(key string) (bool, error) {
+	input := &dynamodb.GetItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			"Symbol": {
+				S: aws.String(key),
+			},
+		},
+		TableName: aws.String(d.TableName),
+	}
+
+	result, err := d.svc.GetItem(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case dynamodb.ErrCodeProvisionedThroughputExceededException:
+				log.Print(dynamodb.ErrCodeProvisionedThroughputExceededException, aerr.Error())
+			case dynamodb.ErrCodeResourceNotFoundException:
+				log.Print(dynamodb.ErrCodeResourceNotFoundException, aerr.Error())
+			case dynamodb.ErrCodeRequestLimitExceeded:
+				log.Print(dynamodb.ErrCodeRequestLimitExceeded, aerr.Error())
+			case dynamodb.ErrCodeInternalServerError:
+				log.Print(dynamodb.ErrCodeInternalServerError, aerr.Error())
+			default:
+				log.Print(aerr.Error())
+			}
+		} else {
+			log.Print(err.Error())
+		}
+	}
+	if result.Item == nil {
+		return false, err
+	}
+
+	return unexpired(key, result.Item)
+}
+
+// unexpired - Determines if the item is the unexpired record for the key, expired items linger until DynamoDB's TTL
+// sweep deletes them
+func unexpired(key string, item map[string]*dynamodb.AttributeValue) (bool, error) {
+	var r Record
+	if err := dynamodbattribute.UnmarshalMap(item, &r); err != nil {
+		return false, err
+	}
+	if r.Symbol != key {
+		return false, fmt.Errorf("Symbol %s returned wrong record %v", key, item)
+	}
+
+	return !r.expired(), nil
+}
+
+// Insert - Inserts the alerted stock into the short-lived cache data store
+func (d *data) Insert(r Record) error {
+	item := newRecord(r)
+
+	av, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(d.TableName),
+	}
+
+	if _, err := d.svc.PutItem(input); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Claim - Inserts the alerted stock with a conditional put, modeled after the archive's
+// attribute_not_exists(Symbol) insert, that only succeeds when no unexpired record exists for its key or the
+// policy allows a follow-up over the existing record
+func (d *data) Claim(r Record, policy ReAlertPolicy) (ClaimResult, error) {
+	item := newRecord(r)
+
+	av, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return ClaimResult{}, err
+	}
+
+	condition, values := claimCondition(item, policy)
+	input := &dynamodb.PutItemInput{
+		Item:                av,
+		TableName:           aws.String(d.TableName),
+		ConditionExpression: aws.String(condition),
+		ExpressionAttributeNames: map[string]*string{
+			"#ttl": aws.String("TTL"),
+		},
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllOld),
+	}
+
+	output, err := d.svc.PutItem(input)
+	if err != nil {
+		aerr, ok := err.(awserr.Error)
+		if !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+			return ClaimResult{}, err
+		}
+		return ClaimResult{}, nil
+	}
+
+	if len(output.Attributes) == 0 {
+		return ClaimResult{Won: true}, nil
+	}
+
+	var previous Record
+	if err = dynamodbattribute.UnmarshalMap(output.Attributes, &previous); err != nil {
+		return ClaimResult{}, err
+	}
+	if previous.TTL <= item.CreatedAtUtc {
+		return ClaimResult{Won: true}, nil
+	}
+	if err = d.keepAlertPrice(item, firstAlertPrice(previous)); err != nil {
+		return ClaimResult{}, err
+	}
+
+	return ClaimResult{Won: true, Update: true, Previous: previous}, nil
+}
+
+// keepAlertPrice - Restores the first alerted price on a re-alert the put replaced, unless a newer claim has
+// replaced the item since
+func (d *data) keepAlertPrice(item Record, alertPrice float64) error {
+	if alertPrice == item.AlertPrice {
+		return nil
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Symbol": {
+				S: aws.String(item.Symbol),
+			},
+		},
+		UpdateExpression:    aws.String("SET AlertPrice = :alertPrice"),
+		ConditionExpression: aws.String("CreatedAtUtc = :createdAt AND Price = :price"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":alertPrice": {
+				N: aws.String(strconv.FormatFloat(alertPrice, 'f', -1, 64)),
+			},
+			":createdAt": {
+				N: aws.String(fmt.Sprintf("%d", item.CreatedAtUtc)),
+			},
+			":price": {
+				N: aws.String(strconv.FormatFloat(item.Price, 'f', -1, 64)),
+			},
+		},
+	}
+
+	if _, err := d.svc.UpdateItem(input); err != nil {
+		aerr, ok := err.(awserr.Error)
+		if !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claimCondition - Returns the put condition and its values matching claim: no record, an expired record or one
+// the policy allows a follow-up over. A previous record without a price never allows a price step follow-up.
+func claimCondition(item Record, policy ReAlertPolicy) (string, map[string]*dynamodb.AttributeValue) {
+	// expired items linger until DynamoDB's TTL sweep deletes them
+	condition := "attribute_not_exists(Symbol) OR #ttl <= :now"
+	values := map[string]*dynamodb.AttributeValue{
+		":now": {
+			N: aws.String(fmt.Sprintf("%d", item.CreatedAtUtc)),
+		},
+	}
+	if policy.GainStep > 0 {
+		condition += " OR Percentage <= :gainCeiling"
+		values[":gainCeiling"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.FormatFloat(item.Percentage-policy.GainStep, 'f', -1, 64)),
+		}
+	}
+	if policy.PriceStep > 0 {
+		condition += " OR (Price > :zero AND Price <= :priceCeiling)"
+		values[":zero"] = &dynamodb.AttributeValue{
+			N: aws.String("0"),
+		}
+		values[":priceCeiling"] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.FormatFloat(item.Price/(1+policy.PriceStep/100), 'f', -1, 64)),
+		}
+	}
+
+	return condition, values
+}
+
+// Recent - Returns the records created since the time
+func (d *data) Recent(since time.Time) ([]Record, error) {
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(d.TableName),
+		FilterExpression: aws.String("CreatedAtUtc >= :since"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":since": {
+				N: aws.String(fmt.Sprintf("%d", since.UTC().Unix())),
+			},
+		},
+	}
+
+	var records []Record
+	var unmarshalErr error
+	err := d.svc.ScanPages(input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var r []Record
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &r); unmarshalErr != nil {
+			return false
+		}
+		records = append(records, r...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, unmarshalErr
+}
+
+type dynamoDBCandidates struct {
+	table
+}
+
+// NewDynamoDBCandidates - Public constructor for the DynamoDB CandidateStore, the table is keyed by Key and
+// expires items by TTL
+func NewDynamoDBCandidates(tableName string) *dynamoDBCandidates {
+	return &dynamoDBCandidates{newTable(tableName)}
+}
+
+// InsertCandidates - Inserts the candidates of a run
+func (d *dynamoDBCandidates) InsertCandidates(candidates []Candidate) error {
+	for _, c := range candidates {
+		av, err := dynamodbattribute.MarshalMap(newCandidate(c))
+		if err != nil {
+			return err
+		}
+
+		input := &dynamodb.PutItemInput{
+			Item:      av,
+			TableName: aws.String(d.TableName),
+		}
+		if _, err = d.svc.PutItem(input); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Candidates - Returns the candidates seen since the time, oldest first
+func (d *dynamoDBCandidates) Candidates(since time.Time) ([]Candidate, error) {
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(d.TableName),
+		FilterExpression: aws.String("CreatedAtUtc >= :since"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":since": {
+				N: aws.String(fmt.Sprintf("%d", since.UTC().Unix())),
+			},
+		},
+	}
+
+	var candidates []Candidate
+	var unmarshalErr error
+	err := d.svc.ScanPages(input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var c []Candidate
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &c); unmarshalErr != nil {
+			return false
+		}
+		candidates = append(candidates, c...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortCandidates(candidates)
+
+	return candidates, unmarshalErr
+}
+
+type dynamoDBQueue struct {
+	table
+}
+
+// NewDynamoDBQueue - Public constructor for the DynamoDB Queue, the table is keyed by Key and expires items by TTL
+func NewDynamoDBQueue(tableName string) *dynamoDBQueue {
+	return &dynamoDBQueue{newTable(tableName)}
+}
+
+// Hold - Queues the alert payloads for the channel
+func (d *dynamoDBQueue) Hold(channel string, payloads []string) error {
+	for _, a := range newHeldAlerts(channel, payloads) {
+		av, err := dynamodbattribute.MarshalMap(a)
+		if err != nil {
+			return err
+		}
+
+		input := &dynamodb.PutItemInput{
+			Item:      av,
+			TableName: aws.String(d.TableName),
+		}
+		if _, err = d.svc.PutItem(input); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Release - Removes and returns the payloads queued for the channel, oldest first. Each item is deleted on the
+// condition that it still exists so concurrent releases never deliver an alert twice.
+func (d *dynamoDBQueue) Release(channel string) ([]string, error) {
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(d.TableName),
+		FilterExpression: aws.String("Channel = :channel"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":channel": {
+				S: aws.String(channel),
+			},
+		},
+	}
+
+	var alerts []HeldAlert
+	var unmarshalErr error
+	err := d.svc.ScanPages(input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var a []HeldAlert
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &a); unmarshalErr != nil {
+			return false
+		}
+		alerts = append(alerts, a...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	var released []HeldAlert
+	for _, a := range alerts {
+		_, err = d.svc.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(d.TableName),
+			Key: map[string]*dynamodb.AttributeValue{
+				"Key": {
+					S: aws.String(a.Key),
+				},
+			},
+			ConditionExpression: aws.String("attribute_exists(#key)"),
+			ExpressionAttributeNames: map[string]*string{
+				"#key": aws.String("Key"),
+			},
+		})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			continue
+		}
+		if err != nil {
+			return payloads(released), err
+		}
+		released = append(released, a)
+	}
+
+	return payloads(released), nil
+}
+
+type dynamoDBKeys struct {
+	table
+}
+
+// NewDynamoDBKeys - Public constructor for the DynamoDB KeyStore, the table is keyed by Key and expires items by TTL
+func NewDynamoDBKeys(tableName string) *dynamoDBKeys {
+	return &dynamoDBKeys{newTable(tableName)}
+}
+
+// UseKey - Atomically counts a request made with the API key unless it's exhausted, and returns its usage
+func (d *dynamoDBKeys) UseKey(id string) (int, bool, error) {
+	requests, exhausted, err := d.update(id, "ADD Requests :one SET #ttl = if_not_exists(#ttl, :ttl)", aws.String("attribute_not_exists(Exhausted) OR Exhausted = :false"), map[string]*dynamodb.AttributeValue{
+		":one": {
+			N: aws.String("1"),
+		},
+		":false": {
+			BOOL: aws.Bool(false),
+		},
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return 0, true, nil // exhausted keys aren't counted
+	}
+
+	return requests, exhausted, err
+}
+
+// ExhaustKey - Marks the API key exhausted for the rest of its quota window
+func (d *dynamoDBKeys) ExhaustKey(id string) error {
+	_, _, err := d.update(id, "SET Exhausted = :exhausted, #ttl = if_not_exists(#ttl, :ttl)", nil, map[string]*dynamodb.AttributeValue{
+		":exhausted": {
+			BOOL: aws.Bool(true),
+		},
+	})
+
+	return err
+}
+
+// update - Applies the update expression to the API key usage item and returns its new usage, the TTL is only set
+// when the item is created so the usage expires after its quota window
+func (d *dynamoDBKeys) update(id string, expression string, condition *string, values map[string]*dynamodb.AttributeValue) (int, bool, error) {
+	values[":ttl"] = &dynamodb.AttributeValue{
+		N: aws.String(strconv.FormatInt(now().Add(keyUsageRetention).Unix(), 10)),
+	}
+
+	result, err := d.svc.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(d.TableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Key": {
+				S: aws.String(id),
+			},
+		},
+		UpdateExpression:    aws.String(expression),
+		ConditionExpression: condition,
+		ExpressionAttributeNames: map[string]*string{
+			"#ttl": aws.String("TTL"),
+		},
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	var u KeyUsage
+	if err = dynamodbattribute.UnmarshalMap(result.Attributes, &u); err != nil {
+		return 0, false, err
+	}
+
+	return u.Requests, u.Exhausted, nil
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestClaimCondition_PriceStep_RequiresPreviousPrice(t *testing.T) {
+	condition, values := claimCondition(Record{Price: 12.5, CreatedAtUtc: 1609772400}, ReAlertPolicy{PriceStep: 25})
+
+	if !strings.Contains(condition, "(Price > :zero AND Price <= :priceCeiling)") {
+		t.Fatalf("Failed with unexpected condition: %s", condition)
+	}
+	if *values[":zero"].N != "0" || *values[":priceCeiling"].N != "10" || *values[":now"].N != "1609772400" {
+		t.Fatalf("Failed with unexpected values: %v", values)
+	}
+	if _, ok := values[":gainCeiling"]; ok || strings.Contains(condition, "Percentage") {
+		t.Fatalf("Failed with unexpected gain step condition: %s", condition)
+	}
+}
+
+func TestUnexpired_ExpiredItem_DoesNotExist(t *testing.T) {
+	inserted := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	item := map[string]*dynamodb.AttributeValue{
+		"Symbol": {S: aws.String("GNOG")},
+		"TTL":    {N: aws.String("1609812000")}, // 2am the next day
+	}
+	tcs := []struct {
+		now      time.Time
+		expected bool
+	}{
+		{inserted, true},
+		{inserted.AddDate(0, 0, 1).Add(12 * time.Hour), false},
+	}
+
+	for _, tc := range tcs {
+		restore := setNow(tc.now)
+		exists, err := unexpired("GNOG", item)
+		restore()
+
+		if err != nil || exists != tc.expected {
+			t.Fatalf("Failed at %s with unexpected response: %v %v", tc.now, exists, err)
+		}
+	}
+
+	if _, err := unexpired("MOON", item); err == nil {
+		t.Fatalf("Failed to reject the wrong record")
+	}
+}
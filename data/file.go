@@ -0,0 +1,367 @@
+package data
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type file struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFile - Public constructor for the Store backed by a local JSON file, used for local runs
+func NewFile(path string) *file {
+	return &file{
+		Path: path,
+	}
+}
+
+// Exists - Determines if an unexpired record for the key exists in the data store
+func (f *file) Exists(key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records, err := f.read()
+	if err != nil {
+		return false, err
+	}
+
+	r, ok := records[key]
+	return ok && !r.expired(), nil
+}
+
+// Insert - Inserts the alerted stock into the data store
+func (f *file) Insert(r Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	item := newRecord(r)
+	records[item.Symbol] = item
+
+	return f.write(records)
+}
+
+// Claim - Inserts the alerted stock unless an unexpired record exists for its key that the policy doesn't allow
+// a follow-up for
+func (f *file) Claim(r Record, policy ReAlertPolicy) (ClaimResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records, err := f.read()
+	if err != nil {
+		return ClaimResult{}, err
+	}
+
+	item := newRecord(r)
+	existing, ok := records[item.Symbol]
+	result := claim(existing, ok, item, policy)
+	if !result.Won {
+		return result, nil
+	}
+	if result.Update {
+		item.AlertPrice = firstAlertPrice(existing)
+	}
+	records[item.Symbol] = item
+
+	return result, f.write(records)
+}
+
+// Recent - Returns the records created since the time
+func (f *file) Recent(since time.Time) ([]Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return recent(records, since), nil
+}
+
+// read - Reads the records from the file, a missing file is an empty store
+func (f *file) read() (map[string]Record, error) {
+	records := make(map[string]Record)
+
+	body, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(body, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// write - Replaces the file with the records, expired records are dropped
+func (f *file) write(records map[string]Record) error {
+	for k, r := range records {
+		if r.expired() {
+			delete(records, k)
+		}
+	}
+
+	return writeJSON(f.Path, records)
+}
+
+// writeJSON - Replaces the file with the value as indented JSON
+func writeJSON(path string, v interface{}) error {
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write then rename so a crash never leaves a partially written store
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+type fileCandidates struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileCandidates - Public constructor for the CandidateStore backed by a local JSON file
+func NewFileCandidates(path string) *fileCandidates {
+	return &fileCandidates{
+		Path: path,
+	}
+}
+
+// InsertCandidates - Appends the candidates of a run to the file, expired candidates are dropped
+func (f *fileCandidates) InsertCandidates(candidates []Candidate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	var kept []Candidate
+	for _, c := range existing {
+		if c.TTL > now().Unix() {
+			kept = append(kept, c)
+		}
+	}
+	for _, c := range candidates {
+		kept = append(kept, newCandidate(c))
+	}
+
+	return writeJSON(f.Path, kept)
+}
+
+// Candidates - Returns the candidates seen since the time, oldest first
+func (f *fileCandidates) Candidates(since time.Time) ([]Candidate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	candidates, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return recentCandidates(candidates, since), nil
+}
+
+// read - Reads the candidates from the file, a missing file is an empty store
+func (f *fileCandidates) read() ([]Candidate, error) {
+	body, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []Candidate
+	if err = json.Unmarshal(body, &candidates); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+type fileQueue struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileQueue - Public constructor for the Queue backed by a local JSON file
+func NewFileQueue(path string) *fileQueue {
+	return &fileQueue{
+		Path: path,
+	}
+}
+
+// Hold - Queues the alert payloads for the channel, expired alerts are dropped
+func (f *fileQueue) Hold(channel string, payloads []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	var kept []HeldAlert
+	for _, a := range existing {
+		if a.TTL > now().Unix() {
+			kept = append(kept, a)
+		}
+	}
+
+	return writeJSON(f.Path, append(kept, newHeldAlerts(channel, payloads)...))
+}
+
+// Release - Removes and returns the payloads queued for the channel, oldest first
+func (f *fileQueue) Release(channel string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var released, kept []HeldAlert
+	for _, a := range existing {
+		if a.Channel == channel {
+			released = append(released, a)
+		} else if a.TTL > now().Unix() {
+			kept = append(kept, a)
+		}
+	}
+	if len(released) == 0 {
+		return nil, nil
+	}
+	if err = writeJSON(f.Path, kept); err != nil {
+		return nil, err
+	}
+
+	return payloads(released), nil
+}
+
+// read - Reads the held alerts from the file, a missing file is an empty queue
+func (f *fileQueue) read() ([]HeldAlert, error) {
+	body, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []HeldAlert
+	if err = json.Unmarshal(body, &alerts); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+type fileKeys struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileKeys - Public constructor for the KeyStore backed by a local JSON file
+func NewFileKeys(path string) *fileKeys {
+	return &fileKeys{
+		Path: path,
+	}
+}
+
+// UseKey - Counts a request made with the API key unless it's exhausted, and returns its usage
+func (f *fileKeys) UseKey(id string) (int, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, err := f.read()
+	if err != nil {
+		return 0, false, err
+	}
+
+	existing, ok := keys[id]
+	u := useKey(existing, ok, id)
+	if u == existing {
+		return u.Requests, u.Exhausted, nil
+	}
+	keys[id] = u
+
+	return u.Requests, u.Exhausted, f.write(keys)
+}
+
+// ExhaustKey - Marks the API key exhausted for the rest of its quota window
+func (f *fileKeys) ExhaustKey(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	existing, ok := keys[id]
+	keys[id] = exhaustKey(existing, ok, id)
+
+	return f.write(keys)
+}
+
+// read - Reads the API key usage from the file, a missing file is no usage
+func (f *fileKeys) read() (map[string]KeyUsage, error) {
+	keys := make(map[string]KeyUsage)
+
+	body, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(body, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// write - Replaces the file with the usage, expired usage is dropped
+func (f *fileKeys) write(keys map[string]KeyUsage) error {
+	for k, u := range keys {
+		if u.expired() {
+			delete(keys, k)
+		}
+	}
+
+	return writeJSON(f.Path, keys)
+}
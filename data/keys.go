@@ -0,0 +1,74 @@
+package data
+
+import "time"
+
+// keyUsageRetention - How long API key usage is kept, covering a monthly quota window
+const keyUsageRetention = 32 * 24 * time.Hour
+
+// KeyUsage - Requests made with an upstream API key in a quota window
+type KeyUsage struct {
+	Key       string // record key, Pool#Fingerprint#Window
+	Requests  int
+	Exhausted bool
+	TTL       int64
+}
+
+// KeyStore - Usage of upstream API keys shared by every container rotating through a key pool
+type KeyStore interface {
+	// UseKey - Counts a request made with the key unless it's exhausted, and returns the requests counted and
+	// whether it's exhausted
+	UseKey(id string) (int, bool, error)
+	// ExhaustKey - Marks the key exhausted for the rest of its quota window
+	ExhaustKey(id string) error
+}
+
+// OpenKeys - Returns the KeyStore of the kind, target is the table name for dynamodb and the path for file
+func OpenKeys(kind string, target string) (KeyStore, error) {
+	if err := checkKind("key store", kind, target); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case KindDynamoDB:
+		return NewDynamoDBKeys(target), nil
+	case KindFile:
+		return NewFileKeys(target), nil
+	}
+
+	return NewMemoryKeys(), nil
+}
+
+// useKey - Counts a request against the usage unless it's exhausted, resetting it once expired
+func useKey(u KeyUsage, exists bool, id string) KeyUsage {
+	if !exists || u.expired() {
+		u = newKeyUsage(id)
+	}
+	if !u.Exhausted {
+		u.Requests++
+	}
+
+	return u
+}
+
+// exhaustKey - Marks the usage exhausted, resetting it once expired
+func exhaustKey(u KeyUsage, exists bool, id string) KeyUsage {
+	if !exists || u.expired() {
+		u = newKeyUsage(id)
+	}
+	u.Exhausted = true
+
+	return u
+}
+
+// newKeyUsage - Returns the empty usage of the key id with its TTL set for insertion
+func newKeyUsage(id string) KeyUsage {
+	return KeyUsage{
+		Key: id,
+		TTL: now().Add(keyUsageRetention).Unix(),
+	}
+}
+
+// expired - Determines if the usage's TTL has passed
+func (u KeyUsage) expired() bool {
+	return u.TTL != 0 && u.TTL <= now().Unix()
+}
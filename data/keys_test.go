@@ -0,0 +1,48 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyStore_UseKey_CountsRequestsUntilExhaustedOrExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "data")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	stores := map[string]KeyStore{
+		KindMemory: NewMemoryKeys(),
+		KindFile:   NewFileKeys(filepath.Join(dir, "keys.json")),
+	}
+
+	start := time.Date(2021, 1, 4, 3, 0, 0, 0, time.UTC)
+	for kind, s := range stores {
+		restore := setNow(start)
+		s.UseKey("fmp#abc#2021-01-04")
+		requests, exhausted, err := s.UseKey("fmp#abc#2021-01-04")
+		if err != nil || requests != 2 || exhausted {
+			t.Fatalf("%s failed with unexpected usage: %d %t %v", kind, requests, exhausted, err)
+		}
+
+		if err = s.ExhaustKey("fmp#abc#2021-01-04"); err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+		requests, exhausted, err = s.UseKey("fmp#abc#2021-01-04")
+		restore()
+		if err != nil || requests != 2 || !exhausted { // exhausted keys aren't counted
+			t.Fatalf("%s failed with unexpected usage: %d %t %v", kind, requests, exhausted, err)
+		}
+
+		restore = setNow(start.Add(keyUsageRetention + time.Hour))
+		requests, exhausted, err = s.UseKey("fmp#abc#2021-01-04")
+		restore()
+		if err != nil || requests != 1 || exhausted {
+			t.Fatalf("%s failed with unexpected expired usage: %d %t %v", kind, requests, exhausted, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package data
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memory struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// NewMemory - Public constructor for the in-memory Store, records only live as long as the process
+func NewMemory() *memory {
+	return &memory{
+		records: make(map[string]Record),
+	}
+}
+
+// Exists - Determines if an unexpired record for the key exists in the data store
+func (m *memory) Exists(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.records[key]
+	return ok && !r.expired(), nil
+}
+
+// Insert - Inserts the alerted stock into the data store
+func (m *memory) Insert(r Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := newRecord(r)
+	m.records[item.Symbol] = item
+
+	return nil
+}
+
+// Claim - Inserts the alerted stock unless an unexpired record exists for its key that the policy doesn't allow
+// a follow-up for
+func (m *memory) Claim(r Record, policy ReAlertPolicy) (ClaimResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := newRecord(r)
+	existing, ok := m.records[item.Symbol]
+	result := claim(existing, ok, item, policy)
+	if result.Update {
+		item.AlertPrice = firstAlertPrice(existing)
+	}
+	if result.Won {
+		m.records[item.Symbol] = item
+	}
+
+	return result, nil
+}
+
+// Recent - Returns the records created since the time
+func (m *memory) Recent(since time.Time) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return recent(m.records, since), nil
+}
+
+// recent - Returns the records created since the time, oldest first
+func recent(records map[string]Record, since time.Time) []Record {
+	var result []Record
+	for _, r := range records {
+		if r.CreatedAtUtc >= since.UTC().Unix() {
+			result = append(result, r)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAtUtc == result[j].CreatedAtUtc {
+			return result[i].Symbol < result[j].Symbol
+		}
+		return result[i].CreatedAtUtc < result[j].CreatedAtUtc
+	})
+
+	return result
+}
+
+type memoryCandidates struct {
+	mu         sync.Mutex
+	candidates []Candidate
+}
+
+// NewMemoryCandidates - Public constructor for the in-memory CandidateStore
+func NewMemoryCandidates() *memoryCandidates {
+	return &memoryCandidates{}
+}
+
+// InsertCandidates - Inserts the candidates of a run
+func (m *memoryCandidates) InsertCandidates(candidates []Candidate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range candidates {
+		m.candidates = append(m.candidates, newCandidate(c))
+	}
+
+	return nil
+}
+
+// Candidates - Returns the candidates seen since the time, oldest first
+func (m *memoryCandidates) Candidates(since time.Time) ([]Candidate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return recentCandidates(m.candidates, since), nil
+}
+
+type memoryQueue struct {
+	mu     sync.Mutex
+	alerts []HeldAlert
+}
+
+// NewMemoryQueue - Public constructor for the in-memory Queue
+func NewMemoryQueue() *memoryQueue {
+	return &memoryQueue{}
+}
+
+// Hold - Queues the alert payloads for the channel
+func (m *memoryQueue) Hold(channel string, payloads []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.alerts = append(m.alerts, newHeldAlerts(channel, payloads)...)
+
+	return nil
+}
+
+// Release - Removes and returns the payloads queued for the channel, oldest first
+func (m *memoryQueue) Release(channel string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var released, kept []HeldAlert
+	for _, a := range m.alerts {
+		if a.Channel == channel {
+			released = append(released, a)
+		} else {
+			kept = append(kept, a)
+		}
+	}
+	m.alerts = kept
+
+	return payloads(released), nil
+}
+
+type memoryKeys struct {
+	mu   sync.Mutex
+	keys map[string]KeyUsage
+}
+
+// NewMemoryKeys - Public constructor for the in-memory KeyStore
+func NewMemoryKeys() *memoryKeys {
+	return &memoryKeys{
+		keys: make(map[string]KeyUsage),
+	}
+}
+
+// UseKey - Counts a request made with the API key unless it's exhausted, and returns its usage
+func (m *memoryKeys) UseKey(id string) (int, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.keys[id]
+	u := useKey(existing, ok, id)
+	m.keys[id] = u
+
+	return u.Requests, u.Exhausted, nil
+}
+
+// ExhaustKey - Marks the API key exhausted for the rest of its quota window
+func (m *memoryKeys) ExhaustKey(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.keys[id]
+	m.keys[id] = exhaustKey(existing, ok, id)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// heldRetention - How long held alerts are kept when they're never released
+const heldRetention = 3 * 24 * time.Hour
+
+// HeldAlert - Alert held back from a notification channel during its quiet hours
+type HeldAlert struct {
+	Key          string // record key, Channel#CreatedAt#Index
+	Channel      string
+	Payload      string // alert as JSON, opaque to the store
+	CreatedAtUtc int64
+	TTL          int64
+}
+
+// Queue - Alerts held for notification channels in quiet hours until they're released as a digest
+type Queue interface {
+	// Hold - Queues the alert payloads for the channel
+	Hold(channel string, payloads []string) error
+	// Release - Removes and returns the payloads queued for the channel, oldest first
+	Release(channel string) ([]string, error)
+}
+
+// OpenQueue - Returns the Queue of the kind, target is the table name for dynamodb and the path for file
+func OpenQueue(kind string, target string) (Queue, error) {
+	if err := checkKind("queue", kind, target); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case KindDynamoDB:
+		return NewDynamoDBQueue(target), nil
+	case KindFile:
+		return NewFileQueue(target), nil
+	}
+
+	return NewMemoryQueue(), nil
+}
+
+// newHeldAlerts - Returns the payloads as held alerts with their keys, creation time and TTL set for insertion
+func newHeldAlerts(channel string, payloads []string) []HeldAlert {
+	t := now()
+
+	alerts := make([]HeldAlert, len(payloads))
+	for i, p := range payloads {
+		alerts[i] = HeldAlert{
+			Key:          fmt.Sprintf("%s%s%020d%s%04d", channel, keySeparator, t.UnixNano(), keySeparator, i),
+			Channel:      channel,
+			Payload:      p,
+			CreatedAtUtc: t.Unix(),
+			TTL:          t.Add(heldRetention).Unix(),
+		}
+	}
+
+	return alerts
+}
+
+// payloads - Returns the payloads of the unexpired alerts, oldest first
+func payloads(alerts []HeldAlert) []string {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].Key < alerts[j].Key
+	})
+
+	var result []string
+	for _, a := range alerts {
+		if a.TTL > now().Unix() {
+			result = append(result, a.Payload)
+		}
+	}
+
+	return result
+}
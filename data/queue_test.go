@@ -0,0 +1,57 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueue_Release_ReturnsHeldPayloadsOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "data")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	queues := map[string]Queue{
+		KindMemory: NewMemoryQueue(),
+		KindFile:   NewFileQueue(filepath.Join(dir, "queue.json")),
+	}
+
+	start := time.Date(2021, 1, 4, 3, 0, 0, 0, time.UTC)
+	for kind, q := range queues {
+		restore := setNow(start)
+		err := q.Hold("slack", []string{"a", "b"})
+		restore()
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+
+		restore = setNow(start.Add(time.Hour))
+		if err = q.Hold("slack", []string{"c"}); err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+		if err = q.Hold("email", []string{"d"}); err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+
+		released, err := q.Release("slack")
+		if err != nil || !reflect.DeepEqual(released, []string{"a", "b", "c"}) {
+			t.Fatalf("%s failed with unexpected payloads: %v %v", kind, released, err)
+		}
+		if released, err = q.Release("slack"); err != nil || len(released) != 0 {
+			t.Fatalf("%s failed with unexpected payloads: %v %v", kind, released, err)
+		}
+		restore()
+
+		restore = setNow(start.Add(heldRetention + time.Hour))
+		released, err = q.Release("email")
+		restore()
+		if err != nil || len(released) != 0 {
+			t.Fatalf("%s failed with unexpected expired payloads: %v %v", kind, released, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testStores - Returns the offline Store implementations and a cleanup func
+func testStores(t *testing.T) (map[string]Store, func()) {
+	dir, err := ioutil.TempDir("", "data")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	return map[string]Store{
+		KindMemory: NewMemory(),
+		KindFile:   NewFile(filepath.Join(dir, "records.json")),
+	}, func() { os.RemoveAll(dir) }
+}
+
+// setNow - Fixes the store clock and returns a func that restores it
+func setNow(t time.Time) func() {
+	original := now
+	now = func() time.Time { return t }
+	return func() { now = original }
+}
+
+func TestStore_Insert_Exists(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for kind, s := range stores {
+		exists, err := s.Exists("GNOG#moonshot")
+		if err != nil || exists {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, exists, err)
+		}
+
+		if err = s.Insert(Record{Ticker: "GNOG", Profile: "moonshot", Percentage: 55, Price: 10}); err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+
+		exists, err = s.Exists("GNOG#moonshot")
+		if err != nil || !exists {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, exists, err)
+		}
+		if exists, _ = s.Exists("GNOG"); exists {
+			t.Fatalf("%s failed with unexpected record for default profile", kind)
+		}
+	}
+}
+
+func TestStore_ExpiredRecord_DoesNotExist(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	inserted := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	for kind, s := range stores {
+		restore := setNow(inserted)
+		if err := s.Insert(Record{Ticker: "GNOG"}); err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+		restore()
+
+		restore = setNow(inserted.AddDate(0, 0, 1).Add(12 * time.Hour))
+		exists, err := s.Exists("GNOG")
+		restore()
+
+		if err != nil || exists {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, exists, err)
+		}
+	}
+}
+
+func TestStore_Recent_ReturnsRecordsSince(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	start := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	for kind, s := range stores {
+		for i, symbol := range []string{"OLD", "GNOG", "MOON"} {
+			restore := setNow(start.Add(time.Duration(i) * time.Hour))
+			if err := s.Insert(Record{Ticker: symbol, Price: float64(i)}); err != nil {
+				t.Fatalf("%s failed with unexpected error: %s", kind, err)
+			}
+			restore()
+		}
+
+		records, err := s.Recent(start.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+
+		if len(records) != 2 || records[0].Ticker != "GNOG" || records[1].Ticker != "MOON" || records[1].Price != 2 {
+			t.Fatalf("%s failed with unexpected response: %v", kind, records)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open(KindMemory, ""); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	for _, kind := range []string{KindDynamoDB, KindFile, "sqlite"} {
+		if _, err := Open(kind, ""); err == nil {
+			t.Fatalf("Failed to reject %s store without target", kind)
+		}
+	}
+}
+
+func TestStore_Claim_OnlyFirstClaimWins(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for kind, s := range stores {
+		won := make(chan bool, 10)
+		for i := 0; i < cap(won); i++ {
+			go func(symbol string) {
+				result, err := s.Claim(Record{Ticker: symbol, Profile: "moonshot"}, ReAlertPolicy{})
+				if err != nil {
+					t.Errorf("%s failed with unexpected error: %s", kind, err)
+				}
+				won <- result.Won
+			}([]string{"GNOG", "gnog"}[i%2])
+		}
+
+		winners := 0
+		for i := 0; i < cap(won); i++ {
+			if <-won {
+				winners++
+			}
+		}
+		if winners != 1 {
+			t.Fatalf("%s failed with unexpected winners: %d", kind, winners)
+		}
+
+		if exists, _ := s.Exists("GNOG#moonshot"); !exists {
+			t.Fatalf("%s failed with missing claimed record", kind)
+		}
+	}
+}
+
+func TestStore_Claim_ExpiredRecord_Wins(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	inserted := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	for kind, s := range stores {
+		restore := setNow(inserted)
+		if result, err := s.Claim(Record{Ticker: "GNOG"}, ReAlertPolicy{}); !result.Won || err != nil {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, result, err)
+		}
+		restore()
+
+		restore = setNow(inserted.AddDate(0, 0, 1).Add(12 * time.Hour))
+		result, err := s.Claim(Record{Ticker: "GNOG"}, ReAlertPolicy{})
+		restore()
+
+		if !result.Won || result.Update || err != nil {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, result, err)
+		}
+	}
+}
+
+func TestStore_Claim_ReAlertPolicy_AllowsUpdate(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	policy := ReAlertPolicy{GainStep: 50, PriceStep: 25}
+	claims := []struct {
+		record   Record
+		expected ClaimResult
+	}{
+		{Record{Ticker: "GNOG", Percentage: 50, Price: 10}, ClaimResult{Won: true}},
+		{Record{Ticker: "GNOG", Percentage: 80, Price: 12}, ClaimResult{}},
+		{Record{Ticker: "GNOG", Percentage: 100, Price: 12}, ClaimResult{Won: true, Update: true}},
+		{Record{Ticker: "GNOG", Percentage: 120, Price: 15}, ClaimResult{Won: true, Update: true}},
+		{Record{Ticker: "GNOG", Percentage: 125, Price: 16}, ClaimResult{}},
+	}
+
+	for kind, s := range stores {
+		var last Record
+		for i, c := range claims {
+			result, err := s.Claim(c.record, policy)
+			if err != nil {
+				t.Fatalf("%s failed with unexpected error: %s", kind, err)
+			}
+
+			if result.Won != c.expected.Won || result.Update != c.expected.Update {
+				t.Fatalf("%s claim %d failed expected:%v actual:%v", kind, i, c.expected, result)
+			}
+			if result.Update && result.Previous.Percentage != last.Percentage {
+				t.Fatalf("%s claim %d failed with unexpected previous: %v", kind, i, result.Previous)
+			}
+			if result.Won {
+				last = c.record
+			}
+		}
+
+		records, err := s.Recent(time.Unix(0, 0))
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", kind, err)
+		}
+		if len(records) != 1 || records[0].Price != 15 || records[0].AlertPrice != 10 {
+			t.Fatalf("%s failed to keep the first alerted price: %v", kind, records)
+		}
+	}
+}
+
+func TestStore_Claim_PreviousWithoutPrice_BlocksPriceStep(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	policy := ReAlertPolicy{PriceStep: 25}
+	for kind, s := range stores {
+		if result, err := s.Claim(Record{Ticker: "GNOG", Percentage: 50}, policy); !result.Won || err != nil {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, result, err)
+		}
+
+		result, err := s.Claim(Record{Ticker: "GNOG", Percentage: 50, Price: 12}, policy)
+		if result.Won || err != nil {
+			t.Fatalf("%s failed with unexpected response: %v %v", kind, result, err)
+		}
+	}
+}
@@ -2,211 +2,183 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/lancehumiston/stonk-lambda/data"
 	"github.com/lancehumiston/stonk-lambda/market"
-	"github.com/lancehumiston/stonk-lambda/notification"
-	"github.com/lancehumiston/stonk-lambda/url"
+	"github.com/lancehumiston/stonk-lambda/scan"
+	"github.com/lancehumiston/stonk-lambda/screen"
 )
 
 var (
-	tableName               string
-	snsTopicArn             string
-	gainThresholdPercentage float64
+	stockDataStore data.Store
+	candidateStore data.CandidateStore
+	queue          data.Queue
+	calendar       *market.Calendar
+	sessions       []market.Session
+	profiles       []screen.Profile
+	notifyDegraded bool // sends the degraded providers summary to the profiles' channels
 )
 
 func init() {
 	var err error
 
-	tableName = os.Getenv("TABLE_NAME")
-	snsTopicArn = os.Getenv("SNS_TOPIC_ARN")
-	threshold := os.Getenv("GAIN_THRESHOLD")
-	if gainThresholdPercentage, err = strconv.ParseFloat(threshold, 64); err != nil {
-		log.Println(err)
-		gainThresholdPercentage = 50
+	if stockDataStore, err = scan.StoreFromEnv(); err != nil {
+		log.Panic(err)
 	}
+	if candidateStore, err = scan.CandidateStoreFromEnv(); err != nil {
+		log.Panic(err)
+	}
+	if queue, err = scan.QueueFromEnv(); err != nil {
+		log.Panic(err)
+	}
+	keys, err := scan.KeyStoreFromEnv()
+	if err != nil {
+		log.Panic(err)
+	}
+	market.SetKeyStore(keys)
+	if calendar, err = scan.CalendarFromEnv(); err != nil {
+		log.Panic(err)
+	}
+	if sessions, err = scan.SessionsFromEnv(); err != nil {
+		log.Panic(err)
+	}
+	if profiles, err = scan.ProfilesFromEnv(); err != nil {
+		log.Panic(err)
+	}
+	notifyDegraded, _ = strconv.ParseBool(os.Getenv("NOTIFY_DEGRADED"))
 }
 
-// validateAgainstTresholds - Verifies that the symbol meets notification thresholds based on price and financialData
-func validateAgainstTresholds(symbol string, price market.Price, rating market.RecommendationRating, financialData market.FinancialData) error {
-	gainPercentage := price.MarketChange.Percent
-	if gainPercentage < gainThresholdPercentage {
-		return fmt.Errorf("%s gain:%.2f is not above threshold:%.2f", symbol, gainPercentage, gainThresholdPercentage)
-	}
-	log.Printf("%s gain:%.2f is above threshold:%.2f", symbol, gainPercentage, gainThresholdPercentage)
+const (
+	// modeDigest - Invocation mode that sends the end-of-day digest instead of scanning
+	modeDigest = "digest"
+	// modeWatch - Invocation mode that sends follow-ups for the day's alerts instead of scanning
+	modeWatch = "watch"
+)
 
-	preMarketPrice := price.PreMarketPrice.USD
-	currentPrice := financialData.CurrentPrice.USD
-	if preMarketPrice > currentPrice {
-		return fmt.Errorf("%s preMarketPrice:%.2f is above currentPrice:%.2f", symbol, preMarketPrice, financialData.CurrentPrice.USD)
-	}
-	log.Printf("%s currentPrice:%.2f is above preMarketPrice:%.2f", symbol, financialData.CurrentPrice.USD, preMarketPrice)
+// invocation - Options passed through the CloudWatch event detail, e.g. {"mode":"losers"} or
+// {"mode":"digest","since":"2021-01-04T00:00:00Z"}
+type invocation struct {
+	Mode  string    `json:"mode"`
+	Since time.Time `json:"since"` // digest and watch start, defaults to the start of the UTC day
+}
 
-	if rating.Sell > 0 || rating.StrongSell > 0 {
-		return fmt.Errorf("%s has sell:%d strongSell:%d rating", symbol, rating.Sell, rating.StrongSell)
+// parseDetail - Returns the options passed through the event
+func parseDetail(event events.CloudWatchEvent) (invocation, error) {
+	var i invocation
+	if len(event.Detail) > 0 {
+		if err := json.Unmarshal(event.Detail, &i); err != nil {
+			return i, err
+		}
 	}
 
-	if rating.Buy == 0 && rating.StrongBuy == 0 {
-		return fmt.Errorf("%s has buy:%d strongBuy:%d rating", symbol, rating.Buy, rating.StrongBuy)
+	return i, nil
+}
+
+// parseInvocation - Returns the top movers direction requested by the event, defaulting to gainers
+func parseInvocation(event events.CloudWatchEvent) (market.Direction, error) {
+	i, err := parseDetail(event)
+	if err != nil {
+		return "", err
 	}
 
-	targetMeanPrice := financialData.TargetMeanPrice.USD
-	fiftyPercentGainPrice := currentPrice * 1.5
-	if targetMeanPrice < fiftyPercentGainPrice {
-		return fmt.Errorf("%s targetMeanPrice:%.2f is less than fiftyPercentGainPrice:%.2f currentPrice:%.2f", symbol, targetMeanPrice, fiftyPercentGainPrice, currentPrice)
+	return market.ParseDirection(i.Mode)
+}
+
+// invocationSince - Returns the digest or watch start requested by the invocation, defaulting to the start of the
+// UTC day
+func invocationSince(i invocation, now time.Time) time.Time {
+	if !i.Since.IsZero() {
+		return i.Since
 	}
 
-	return nil
+	return now.UTC().Truncate(24 * time.Hour)
 }
 
-// getFilteredStocks - Filters the collection of symbols to those that meet the notificaiton criteria
-// and returns a filtered collection of Stock structs
-func getFilteredStocks(symbols []string) ([]notification.Stock, error) {
-	var notifications []notification.Stock
-	stockDataStore := data.New(tableName)
-
-	uniqueSymbols := unique(symbols)
-	ch := make(chan notification.Stock, len(uniqueSymbols))
-	errCh := make(chan error, cap(ch))
-	for _, v := range uniqueSymbols {
-		go func(symbol string, ch chan<- notification.Stock, errCh chan<- error) {
-			price, rating, data, err := market.GetAnalysis(symbol)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			if err = validateAgainstTresholds(symbol, price, rating, data); err != nil {
-				errCh <- err
-				return
-			}
-
-			exists, err := stockDataStore.Exists(symbol)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			if exists {
-				errCh <- fmt.Errorf("dynamodb record exists for %s", symbol)
-				return
-			}
-
-			gainPercentage := price.MarketChange.Percent
-			if err := stockDataStore.Insert(symbol, gainPercentage, data.CurrentPrice.USD); err != nil {
-				errCh <- err
-				return
-			}
-
-			companyName, err := market.GetCompanyName(symbol)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			newsURL, err := market.GetNews(companyName)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			shortenedNewsURL, err := url.GetShortenedAlias(newsURL)
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			ch <- notification.Stock{
-				Symbol:          symbol,
-				Gain:            gainPercentage,
-				CurrentPrice:    data.CurrentPrice.USD,
-				TargetLowPrice:  data.TargetLowPrice.USD,
-				TargetHighPrice: data.TargetHighPrice.USD,
-				TargetMeanPrice: data.TargetMeanPrice.USD,
-				StrongBuy:       rating.StrongBuy,
-				Buy:             rating.Buy,
-				Hold:            rating.Hold,
-				Sell:            rating.Sell,
-				StrongSell:      rating.StrongSell,
-				NewsURL:         shortenedNewsURL,
-			}
-		}(v, ch, errCh)
-	}
-
-	for i := 0; i < cap(ch); i++ {
-		select {
-		case notification := <-ch:
-			notifications = append(notifications, notification)
-		case err := <-errCh:
-			log.Println(err) // log and continue with data from other stocks
+// inSession - Determines if the market is in one of the sessions at t
+func inSession(calendar *market.Calendar, sessions []market.Session, t time.Time) bool {
+	current := calendar.Session(t)
+	for _, s := range sessions {
+		if s == current {
+			return true
 		}
 	}
 
-	return notifications, nil
+	return false
 }
 
-func unique(items []string) []string {
-	if items == nil || len(items) == 0 {
-		return items
+// reportDegraded - Logs the upstream providers whose breaker tripped during the run and, with NOTIFY_DEGRADED set,
+// sends the summary to the profiles' channels
+func reportDegraded() {
+	degraded := market.DegradedProviders()
+	if len(degraded) == 0 {
+		return
 	}
 
-	var uniqueItems []string
-	set := make(map[string]struct{})
-	for _, v := range items {
-		if _, ok := set[v]; ok {
-			continue
+	log.Println(market.FormatDegraded(degraded))
+	if notifyDegraded {
+		s := scan.New(profiles, stockDataStore)
+		s.Queue = queue
+		if err := s.NotifyDegraded(degraded); err != nil {
+			log.Println(err)
 		}
-
-		uniqueItems = append(uniqueItems, v)
-		set[v] = struct{}{}
 	}
-
-	return uniqueItems
 }
 
 // lambdaHandler - Entry point
 func lambdaHandler(ctx context.Context, event events.CloudWatchEvent) error {
-	var symbols []string
-	providers := market.GetTopMoversProviders()
-
-	ch := make(chan []string, len(providers))
-	errCh := make(chan error, cap(ch))
-	for _, v := range providers {
-		go func(provider market.TopMoversProvider, ch chan<- []string, errCh chan<- error) {
-			topMovers, err := provider.GetTopMovers()
-			if err != nil {
-				errCh <- err
-				return
-			}
-
-			ch <- topMovers
-		}(v, ch, errCh)
-	}
-
-	for i := 0; i < cap(ch); i++ {
-		select {
-		case topMovers := <-ch:
-			symbols = append(symbols, topMovers...)
-		case err := <-errCh:
-			log.Println(err) // log and continue with data from other providers
+	market.ResetBreakers()
+	defer reportDegraded()
+
+	i, err := parseDetail(event)
+	if err != nil {
+		return err
+	}
+	if i.Mode == modeDigest {
+		if candidateStore == nil {
+			return errors.New("CANDIDATES_TABLE_NAME or DATA_STORE must be configured for digests")
 		}
+		s := scan.New(profiles, stockDataStore)
+		s.Candidates = candidateStore
+		s.Queue = queue
+		_, err = s.Digest(invocationSince(i, time.Now()))
+		return err
 	}
 
-	filteredStocks, err := getFilteredStocks(symbols)
-	if err != nil {
+	if stockDataStore == nil {
+		return errors.New("TABLE_NAME or DATA_STORE must be configured")
+	}
+	if now := time.Now(); !inSession(calendar, sessions, now) {
+		log.Printf("Skipping scan, the market is %s and scans run in %v", calendar.Session(now), sessions)
+		return nil
+	}
+	if i.Mode == modeWatch {
+		s := scan.New(profiles, stockDataStore)
+		s.Queue = queue
+		_, err = s.Watch(invocationSince(i, time.Now()))
 		return err
 	}
 
-	notification := notification.New(snsTopicArn)
-	if err = notification.Send(filteredStocks); err != nil {
+	direction, err := parseInvocation(event)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	s := scan.New(profiles, stockDataStore)
+	s.Candidates = candidateStore
+	s.Queue = queue
+	result, err := s.Run(direction)
+	if len(result.Errors) > 0 {
+		log.Printf("Skipped %d unknown symbols, %d provider errors: %v", result.SkippedSymbols(), result.ProviderErrors(), result.Errors)
+	}
+	return err
 }
 
 func main() {
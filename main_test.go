@@ -1,258 +1,74 @@
 package main
 
 import (
-	"errors"
-	"fmt"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/lancehumiston/stonk-lambda/market"
 )
 
-const gainThreshold float64 = 50
-
-func TestUnique_Duplicates_ReturnsUniqueItems(t *testing.T) {
-	items := []string{
-		"a",
-		"b",
-		"c",
-		"b",
-		"c",
-		"d",
-	}
-	expected := []string{
-		"a",
-		"b",
-		"c",
-		"d",
+func TestParseInvocation(t *testing.T) {
+	tcs := []struct {
+		detail   string
+		expected market.Direction
+	}{
+		{"", market.Gainers},
+		{`{}`, market.Gainers},
+		{`{"mode":"gainers"}`, market.Gainers},
+		{`{"mode":"losers"}`, market.Losers},
 	}
 
-	result := unique(items)
+	for _, tc := range tcs {
+		actual, err := parseInvocation(events.CloudWatchEvent{Detail: json.RawMessage(tc.detail)})
+		if err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+		if actual != tc.expected {
+			t.Fatalf("Failed expected:%s actual:%s", tc.expected, actual)
+		}
+	}
 
-	if !IsEqual(result, expected) {
-		t.Fatalf("Failed expected:%v actual:%v", expected, result)
+	if _, err := parseInvocation(events.CloudWatchEvent{Detail: json.RawMessage(`{"mode":"sideways"}`)}); err == nil {
+		t.Fatal("Failed to reject unknown mode")
 	}
 }
 
-func IsEqual(a, b []string) bool {
-	if (a == nil) != (b == nil) {
-		return false
-	}
+func TestInvocationSince(t *testing.T) {
+	now := time.Date(2021, 1, 4, 21, 15, 0, 0, time.UTC)
 
-	if len(a) != len(b) {
-		return false
+	i, err := parseDetail(events.CloudWatchEvent{Detail: json.RawMessage(`{"mode":"digest"}`)})
+	if err != nil || i.Mode != modeDigest {
+		t.Fatalf("Failed with unexpected response: %v %v", i, err)
 	}
-
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
+	if actual := invocationSince(i, now); !actual.Equal(time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Failed with unexpected default since: %s", actual)
 	}
 
-	return true
-}
-
-type testCase struct {
-	name          string
-	symbol        string
-	price         market.Price
-	rating        market.RecommendationRating
-	financialData market.FinancialData
-	expected      error
-}
-
-var validateAgainstTresholdsTCs = []testCase{
-	{
-		name:   "Success targetMeanPrice > 1.5*currentPrice w/ buy rating",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 9,
-			},
-		},
-		rating: market.RecommendationRating{
-			Buy: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 10,
-			},
-			TargetMeanPrice: market.Currency{
-				USD: 15,
-			},
-		},
-		expected: nil,
-	},
-	{
-		name:   "Success targetMeanPrice > 1.5*currentPrice w/ strongBuy rating",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 9,
-			},
-		},
-		rating: market.RecommendationRating{
-			StrongBuy: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 10,
-			},
-			TargetMeanPrice: market.Currency{
-				USD: 15,
-			},
-		},
-		expected: nil,
-	},
-	{
-		name:   "Fail gainPercentage below threshold",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold - 1,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 10,
-			},
-		},
-		rating: market.RecommendationRating{
-			StrongBuy: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 15,
-			},
-			TargetMeanPrice: market.Currency{},
-		},
-		expected: errors.New("GNOG gain:49.00 is not above threshold:50.00"),
-	},
-	{
-		name:   "Fail preMarketPrice is above currentPrice",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 15,
-			},
-		},
-		rating: market.RecommendationRating{
-			StrongBuy: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 10,
-			},
-			TargetMeanPrice: market.Currency{},
-		},
-		expected: errors.New("GNOG preMarketPrice:15.00 is above currentPrice:10.00"),
-	},
-	{
-		name:   "Fail no targetMeanPrice",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 10,
-			},
-		},
-		rating: market.RecommendationRating{
-			Buy: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 15,
-			},
-			TargetMeanPrice: market.Currency{},
-		},
-		expected: errors.New("GNOG targetMeanPrice:0.00 is less than fiftyPercentGainPrice:22.50 currentPrice:15.00"),
-	},
-	{
-		name:   "Fail sell",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 9,
-			},
-		},
-		rating: market.RecommendationRating{
-			Sell: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 10,
-			},
-			TargetMeanPrice: market.Currency{
-				USD: 15,
-			},
-		},
-		expected: errors.New("GNOG has sell:1 strongSell:0 rating"),
-	},
-	{
-		name:   "Fail strongSell",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 9,
-			},
-		},
-		rating: market.RecommendationRating{
-			StrongSell: 1,
-		},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 10,
-			},
-			TargetMeanPrice: market.Currency{
-				USD: 15,
-			},
-		},
-		expected: errors.New("GNOG has sell:0 strongSell:1 rating"),
-	},
-	{
-		name:   "Fail no buy or strongBuy",
-		symbol: "GNOG",
-		price: market.Price{
-			MarketChange: market.Percent{
-				Percent: gainThreshold,
-			},
-			PreMarketPrice: market.Currency{
-				USD: 9,
-			},
-		},
-		rating: market.RecommendationRating{},
-		financialData: market.FinancialData{
-			CurrentPrice: market.Currency{
-				USD: 10,
-			},
-			TargetMeanPrice: market.Currency{
-				USD: 15,
-			},
-		},
-		expected: errors.New("GNOG has buy:0 strongBuy:0 rating"),
-	},
+	i, err = parseDetail(events.CloudWatchEvent{Detail: json.RawMessage(`{"mode":"digest","since":"2021-01-01T14:30:00Z"}`)})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if actual := invocationSince(i, now); !actual.Equal(time.Date(2021, 1, 1, 14, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Failed with unexpected since: %s", actual)
+	}
 }
 
-func TestValidateAgainstTresholds(t *testing.T) {
-	for _, tc := range validateAgainstTresholdsTCs {
-		actual := validateAgainstTresholds(tc.symbol, tc.price, tc.rating, tc.financialData)
+func TestInSession(t *testing.T) {
+	calendar, err := market.NewCalendar()
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	sessions := []market.Session{market.SessionRegular}
 
-		if fmt.Sprintf("%v", actual) != fmt.Sprintf("%v", tc.expected) {
-			t.Fatalf("%s expected: %v, actual: %v", tc.name, tc.expected, actual)
-		}
+	if !inSession(calendar, sessions, time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Failed with unexpected closed market")
+	}
+	if inSession(calendar, sessions, time.Date(2021, 1, 4, 22, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Failed with unexpected open market after hours")
+	}
+	if inSession(calendar, sessions, time.Date(2021, 1, 18, 15, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Failed with unexpected open market on Martin Luther King, Jr. Day")
 	}
 }
@@ -0,0 +1,221 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen - Returned instead of calling an upstream host whose breaker tripped
+var ErrCircuitOpen = errors.New("circuit open")
+
+const (
+	// breakerThreshold - Consecutive failures that trip a host's breaker
+	breakerThreshold = 5
+	// breakerCooldown - How long a tripped breaker short-circuits calls before letting a trial call through
+	breakerCooldown = 5 * time.Minute
+)
+
+// Degraded - Upstream host whose breaker tripped
+type Degraded struct {
+	Host      string
+	Open      bool // still short-circuiting calls
+	Failures  int  // failed calls
+	Skipped   int  // calls short-circuited
+	LastError string
+}
+
+func (d Degraded) String() string {
+	state := "recovered"
+	if d.Open {
+		state = "open"
+	}
+
+	return fmt.Sprintf("%s: %s, %d failed, %d skipped, last error: %s", d.Host, state, d.Failures, d.Skipped, d.LastError)
+}
+
+// FormatDegraded - Summarizes the degraded providers, one line per host
+func FormatDegraded(degraded []Degraded) string {
+	lines := []string{fmt.Sprintf("⚠️ %d degraded providers", len(degraded))}
+	for _, d := range degraded {
+		lines = append(lines, d.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type circuit struct {
+	consecutive int
+	failures    int
+	skipped     int
+	trips       int
+	open        bool
+	trial       bool // a call is testing whether the host recovered
+	openedAt    time.Time
+	lastError   string
+}
+
+type breaker struct {
+	next      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// newBreaker - Returns an http.RoundTripper that short-circuits calls to a host with ErrCircuitOpen once it fails
+// threshold times in a row. Errors, 429 and 5xx responses are failures.
+func newBreaker(next http.RoundTripper, threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// breakers - Breakers of the shared client, one circuit per host
+var breakers = newBreaker(NewTransport(http.DefaultTransport, Policies), breakerThreshold, breakerCooldown)
+
+// defaultClient - HTTP client shared by the upstreams so each host's rate limit and breaker apply across goroutines
+var defaultClient = &http.Client{
+	Transport: breakers,
+	Timeout:   time.Minute,
+}
+
+// DegradedProviders - Returns the upstream hosts whose breaker tripped since the last ResetBreakers, by host
+func DegradedProviders() []Degraded {
+	return breakers.degraded()
+}
+
+// ResetBreakers - Closes every breaker and clears their counts, called at the start of a run so a warm container
+// retries the hosts that failed in the previous run
+func ResetBreakers() {
+	breakers.reset()
+}
+
+// RoundTrip - Implementation of the http.RoundTripper interface
+func (b *breaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if !b.allow(host) {
+		return nil, fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+	}
+
+	resp, err := b.next.RoundTrip(req)
+	switch {
+	case err != nil:
+		b.fail(host, err.Error())
+	case retryable(resp.StatusCode):
+		b.fail(host, resp.Status)
+	default:
+		b.succeed(host)
+	}
+
+	return resp, err
+}
+
+// allow - Determines if a call to the host can go through, a single trial call is let through once the cooldown
+// of a tripped breaker has passed
+func (b *breaker) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[host]
+	if !ok || !c.open {
+		return true
+	}
+	if !c.trial && b.now().Sub(c.openedAt) >= b.cooldown {
+		c.trial = true
+		return true
+	}
+
+	c.skipped++
+	return false
+}
+
+// fail - Records a failed call, tripping the breaker after threshold consecutive failures or a failed trial call
+func (b *breaker) fail(host string, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(host)
+	c.consecutive++
+	c.failures++
+	c.lastError = reason
+	if c.open {
+		// the trial call failed, wait out another cooldown
+		c.trial = false
+		c.openedAt = b.now()
+		return
+	}
+	if c.consecutive >= b.threshold {
+		log.Printf("%s: tripped after %d consecutive failures, last error: %s", host, c.consecutive, reason)
+		c.open = true
+		c.trips++
+		c.openedAt = b.now()
+	}
+}
+
+// succeed - Records a successful call, closing the breaker
+func (b *breaker) succeed(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(host)
+	if c.open {
+		log.Printf("%s: recovered", host)
+	}
+	c.consecutive = 0
+	c.open = false
+	c.trial = false
+}
+
+// circuit - Returns the host's circuit, the caller holds the lock
+func (b *breaker) circuit(host string) *circuit {
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{}
+		b.circuits[host] = c
+	}
+
+	return c
+}
+
+func (b *breaker) degraded() []Degraded {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var degraded []Degraded
+	for host, c := range b.circuits {
+		if c.trips == 0 {
+			continue
+		}
+		degraded = append(degraded, Degraded{
+			Host:      host,
+			Open:      c.open,
+			Failures:  c.failures,
+			Skipped:   c.skipped,
+			LastError: c.lastError,
+		})
+	}
+	sort.Slice(degraded, func(i, j int) bool {
+		return degraded[i].Host < degraded[j].Host
+	})
+
+	return degraded
+}
+
+func (b *breaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.circuits = make(map[string]*circuit)
+}
@@ -0,0 +1,81 @@
+package market
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBreaker_ConsecutiveFailures_ShortCircuits(t *testing.T) {
+	next := &scripted{statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}}
+	start := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	b := newBreaker(next, 2, time.Minute)
+	b.now = func() time.Time { return start }
+
+	req, _ := http.NewRequest(http.MethodGet, "https://query2.finance.yahoo.com/quote", nil)
+	for i := 0; i < 2; i++ {
+		if _, err := b.RoundTrip(req); err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+	}
+	if _, err := b.RoundTrip(req); !errors.Is(err, ErrCircuitOpen) || next.calls != 2 {
+		t.Fatalf("Failed with unexpected call through an open breaker: %v after %d calls", err, next.calls)
+	}
+
+	other, _ := http.NewRequest(http.MethodGet, "https://api.robinhood.com/movers", nil)
+	if _, err := b.RoundTrip(other); err != nil {
+		t.Fatalf("Failed with unexpected error for another host: %s", err)
+	}
+
+	degraded := b.degraded()
+	if len(degraded) != 1 || degraded[0].Host != "query2.finance.yahoo.com" || !degraded[0].Open ||
+		degraded[0].Failures != 2 || degraded[0].Skipped != 1 || degraded[0].LastError != http.StatusText(http.StatusBadGateway) {
+		t.Fatalf("Failed with unexpected degraded providers: %v", degraded)
+	}
+}
+
+func TestBreaker_Cooldown_TrialCallCloses(t *testing.T) {
+	next := &scripted{statuses: []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK}}
+	start := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	b := newBreaker(next, 1, time.Minute)
+	b.now = func() time.Time { return start }
+
+	req, _ := http.NewRequest(http.MethodGet, "https://query2.finance.yahoo.com/quote", nil)
+	b.RoundTrip(req)
+	if _, err := b.RoundTrip(req); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Failed with unexpected call through an open breaker: %v", err)
+	}
+
+	b.now = func() time.Time { return start.Add(time.Minute) }
+	if _, err := b.RoundTrip(req); err != nil {
+		t.Fatalf("Failed with unexpected error for the trial call: %s", err)
+	}
+	if _, err := b.RoundTrip(req); err != nil {
+		t.Fatalf("Failed with unexpected error after recovering: %s", err)
+	}
+
+	degraded := b.degraded()
+	if len(degraded) != 1 || degraded[0].Open {
+		t.Fatalf("Failed with unexpected degraded providers: %v", degraded)
+	}
+
+	b.reset()
+	if degraded = b.degraded(); len(degraded) != 0 {
+		t.Fatalf("Failed with unexpected degraded providers after reset: %v", degraded)
+	}
+}
+
+func TestFormatDegraded(t *testing.T) {
+	actual := FormatDegraded([]Degraded{
+		{Host: "financialmodelingprep.com", Failures: 5, LastError: "429 Too Many Requests"},
+		{Host: "query2.finance.yahoo.com", Open: true, Failures: 5, Skipped: 12, LastError: "503 Service Unavailable"},
+	})
+
+	expected := "⚠️ 2 degraded providers\n" +
+		"financialmodelingprep.com: recovered, 5 failed, 0 skipped, last error: 429 Too Many Requests\n" +
+		"query2.finance.yahoo.com: open, 5 failed, 12 skipped, last error: 503 Service Unavailable"
+	if actual != expected {
+		t.Fatalf("Failed with unexpected summary:\n%s", actual)
+	}
+}
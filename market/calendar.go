@@ -0,0 +1,285 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Session - Trading session of the market
+type Session string
+
+const (
+	// SessionPre - Pre-market, 4:00 to 9:30 ET
+	SessionPre Session = "pre"
+	// SessionRegular - Regular trading hours, 9:30 to 16:00 ET or 13:00 ET on early close days
+	SessionRegular Session = "regular"
+	// SessionPost - After-hours, until 20:00 ET or 17:00 ET on early close days
+	SessionPost Session = "post"
+	// SessionClosed - Overnight, weekends and holidays
+	SessionClosed Session = "closed"
+)
+
+// ParseSession - Returns the named session
+func ParseSession(s string) (Session, error) {
+	switch session := Session(s); session {
+	case SessionPre, SessionRegular, SessionPost, SessionClosed:
+		return session, nil
+	}
+
+	return "", fmt.Errorf("unknown session %q", s)
+}
+
+const dateLayout = "2006-01-02"
+
+// CalendarDay - Market holiday or early close from the calendar file
+type CalendarDay struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+// calendarFile - Special closures that the holiday rules don't cover, e.g. national days of mourning
+type calendarFile struct {
+	Holidays    []CalendarDay `json:"holidays"`
+	EarlyCloses []CalendarDay `json:"earlyCloses"`
+}
+
+// Calendar - NYSE trading calendar: the standing holiday and early close rules plus the days from a calendar file
+type Calendar struct {
+	location    *time.Location
+	holidays    map[string]string
+	earlyCloses map[string]string
+}
+
+// NewCalendar - Returns the NYSE calendar from the holiday rules and the special closures in nyseCalendar
+func NewCalendar() (*Calendar, error) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calendar{
+		location:    location,
+		holidays:    make(map[string]string),
+		earlyCloses: make(map[string]string),
+	}
+	if err = c.add([]byte(nyseCalendar), "nyse_calendar"); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadCalendar - Returns the NYSE calendar with the holidays and early closes in the JSON file added, a day in the
+// file overrides the built-in one
+func LoadCalendar(path string) (*Calendar, error) {
+	c, err := NewCalendar()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.add(body, path); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// add - Adds the holidays and early closes in the calendar file body
+func (c *Calendar) add(body []byte, source string) error {
+	var f calendarFile
+	if err := json.Unmarshal(body, &f); err != nil {
+		return fmt.Errorf("calendar file %s: %w", source, err)
+	}
+	for _, days := range []struct {
+		days []CalendarDay
+		into map[string]string
+	}{
+		{f.Holidays, c.holidays},
+		{f.EarlyCloses, c.earlyCloses},
+	} {
+		for _, d := range days.days {
+			if _, err := time.Parse(dateLayout, d.Date); err != nil {
+				return fmt.Errorf("calendar file %s: %w", source, err)
+			}
+			days.into[d.Date] = d.Name
+		}
+	}
+
+	return nil
+}
+
+// Holiday - Returns the name of the holiday the market is closed for on the day of t in New York
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	t = t.In(c.location)
+	if name, ok := c.holidays[t.Format(dateLayout)]; ok {
+		return name, true
+	}
+
+	return holiday(t.Year(), t.Month(), t.Day())
+}
+
+// EarlyClose - Determines if the market closes at 13:00 ET on the day of t
+func (c *Calendar) EarlyClose(t time.Time) bool {
+	t = t.In(c.location)
+	if _, ok := c.earlyCloses[t.Format(dateLayout)]; ok {
+		return true
+	}
+
+	return earlyClose(t.Year(), t.Month(), t.Day())
+}
+
+// TradingDay - Determines if the market is open on the day of t in New York
+func (c *Calendar) TradingDay(t time.Time) bool {
+	t = t.In(c.location)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+
+	_, ok := c.Holiday(t)
+	return !ok
+}
+
+// Session - Returns the trading session at t
+func (c *Calendar) Session(t time.Time) Session {
+	if !c.TradingDay(t) {
+		return SessionClosed
+	}
+
+	t = t.In(c.location)
+	minute := t.Hour()*60 + t.Minute()
+	closeMinute, postMinute := 16*60, 20*60
+	if c.EarlyClose(t) {
+		closeMinute, postMinute = 13*60, 17*60
+	}
+
+	switch {
+	case minute < 4*60:
+		return SessionClosed
+	case minute < 9*60+30:
+		return SessionPre
+	case minute < closeMinute:
+		return SessionRegular
+	case minute < postMinute:
+		return SessionPost
+	}
+
+	return SessionClosed
+}
+
+// holiday - Returns the NYSE holiday observed on the date under the standing rules
+func holiday(year int, month time.Month, day int) (string, bool) {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	fixed := []struct {
+		month time.Month
+		day   int
+		name  string
+		since int
+	}{
+		{time.January, 1, "New Year's Day", 0},
+		{time.June, 19, "Juneteenth", 2022},
+		{time.July, 4, "Independence Day", 0},
+		{time.December, 25, "Christmas Day", 0},
+	}
+	for _, f := range fixed {
+		if year < f.since {
+			continue
+		}
+		if observed(year, f.month, f.day).Equal(date) {
+			return f.name, true
+		}
+	}
+	// New Year's Day on a Saturday isn't observed on the Friday before
+	if month == time.December && day == 31 {
+		return "", false
+	}
+
+	floating := []struct {
+		date time.Time
+		name string
+	}{
+		{nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King, Jr. Day"},
+		{nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday"},
+		{easter(year).AddDate(0, 0, -2), "Good Friday"},
+		{nthWeekday(year, time.June, time.Monday, 1).AddDate(0, 0, -7), "Memorial Day"},
+		{nthWeekday(year, time.September, time.Monday, 1), "Labor Day"},
+		{nthWeekday(year, time.November, time.Thursday, 4), "Thanksgiving Day"},
+	}
+	for _, f := range floating {
+		if f.date.Equal(date) {
+			return f.name, true
+		}
+	}
+
+	return "", false
+}
+
+// earlyClose - Determines if the market closes early on the date under the standing rules: the day before
+// Independence Day, the day after Thanksgiving and Christmas Eve, when they're trading days
+func earlyClose(year int, month time.Month, day int) bool {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+	if _, ok := holiday(year, month, day); ok {
+		return false
+	}
+
+	switch {
+	case month == time.July && day == 3:
+		return true
+	case month == time.December && day == 24:
+		return true
+	case date.Equal(nthWeekday(year, time.November, time.Thursday, 4).AddDate(0, 0, 1)):
+		return true
+	}
+
+	return false
+}
+
+// observed - Returns the weekday a fixed date holiday is observed on, Friday for Saturday and Monday for Sunday
+func observed(year int, month time.Month, day int) time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	switch date.Weekday() {
+	case time.Saturday:
+		return date.AddDate(0, 0, -1)
+	case time.Sunday:
+		return date.AddDate(0, 0, 1)
+	}
+
+	return date
+}
+
+// nthWeekday - Returns the nth weekday of the month, e.g. the 3rd Monday of January
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+
+	return first.AddDate(0, 0, offset+(n-1)*7)
+}
+
+// easter - Returns Easter Sunday of the year (anonymous Gregorian algorithm)
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,132 @@
+package market
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newYork(t *testing.T, value string) time.Time {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	at, err := time.ParseInLocation("2006-01-02 15:04", value, location)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	return at
+}
+
+func TestHoliday_StandingRules(t *testing.T) {
+	holidays := []string{
+		"2024-01-01", "2024-01-15", "2024-02-19", "2024-03-29", "2024-05-27", "2024-06-19",
+		"2024-07-04", "2024-09-02", "2024-11-28", "2024-12-25",
+		"2022-12-26", // Christmas on a Sunday
+		"2026-07-03", // Independence Day on a Saturday
+		"2027-12-24", // Christmas on a Saturday
+	}
+	for _, d := range holidays {
+		date, _ := time.Parse(dateLayout, d)
+		if _, ok := holiday(date.Year(), date.Month(), date.Day()); !ok {
+			t.Fatalf("Failed with unexpected trading day: %s", d)
+		}
+	}
+
+	tradingDays := []string{
+		"2021-06-18", // before Juneteenth was observed
+		"2021-12-31", // New Year's Day on a Saturday
+		"2024-03-28", "2024-11-29",
+	}
+	for _, d := range tradingDays {
+		date, _ := time.Parse(dateLayout, d)
+		if name, ok := holiday(date.Year(), date.Month(), date.Day()); ok {
+			t.Fatalf("Failed with unexpected holiday %q: %s", name, d)
+		}
+	}
+}
+
+func TestEarlyClose_StandingRules(t *testing.T) {
+	for d, expected := range map[string]bool{
+		"2024-07-03": true,
+		"2024-11-29": true,
+		"2024-12-24": true,
+		"2023-12-22": false,
+		"2026-07-02": false,
+		"2026-07-03": false, // observed Independence Day
+	} {
+		date, _ := time.Parse(dateLayout, d)
+		if actual := earlyClose(date.Year(), date.Month(), date.Day()); actual != expected {
+			t.Fatalf("Failed with unexpected early close for %s: %v", d, actual)
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	c, err := NewCalendar()
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	for at, expected := range map[string]Session{
+		"2024-03-12 03:59": SessionClosed,
+		"2024-03-12 04:00": SessionPre,
+		"2024-03-12 09:30": SessionRegular,
+		"2024-03-12 15:59": SessionRegular,
+		"2024-03-12 16:00": SessionPost,
+		"2024-03-12 20:00": SessionClosed,
+		"2024-03-16 12:00": SessionClosed, // Saturday
+		"2024-03-29 12:00": SessionClosed, // Good Friday
+		"2024-11-29 13:30": SessionPost,   // early close
+		"2024-11-29 17:00": SessionClosed,
+	} {
+		if actual := c.Session(newYork(t, at)); actual != expected {
+			t.Fatalf("Failed with unexpected session for %s: %s", at, actual)
+		}
+	}
+}
+
+func TestNewCalendar_SpecialClosures_AreHolidays(t *testing.T) {
+	c, err := NewCalendar()
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if c.TradingDay(newYork(t, "2025-01-09 12:00")) {
+		t.Fatalf("Failed with unexpected trading day")
+	}
+	if !c.TradingDay(newYork(t, "2025-01-08 12:00")) {
+		t.Fatalf("Failed with unexpected holiday")
+	}
+}
+
+func TestLoadCalendar_File_AddsToSpecialClosures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "market")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "calendar.json")
+	body := `{"holidays":[{"date":"2025-01-09","name":"Closed"}],"earlyCloses":[{"date":"2025-01-08","name":"Storm"}]}`
+	if err = ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	c, err := LoadCalendar(path)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if name, ok := c.Holiday(newYork(t, "2025-01-09 12:00")); !ok || name != "Closed" {
+		t.Fatalf("Failed with unexpected holiday: %s %t", name, ok)
+	}
+	if _, ok := c.Holiday(newYork(t, "2018-12-05 12:00")); !ok {
+		t.Fatalf("Failed to keep the built-in special closures")
+	}
+	if !c.EarlyClose(newYork(t, "2025-01-08 12:00")) {
+		t.Fatalf("Failed with unexpected regular day")
+	}
+}
@@ -0,0 +1,106 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrSymbolNotFound - The upstream doesn't know the symbol, skip it
+	ErrSymbolNotFound = errors.New("symbol not found")
+	// ErrRateLimited - The upstream rejected the request for exceeding its rate limit or quota
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnauthorized - The upstream rejected the API key
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrSchemaChanged - The upstream response doesn't match the expected schema
+	ErrSchemaChanged = errors.New("schema changed")
+)
+
+// KeyError - The upstream explicitly rejected the API key in its response body, for its quota (ErrRateLimited) or
+// as invalid (ErrUnauthorized), so the key is exhausted until its quota resets
+type KeyError struct {
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - Returns the error the upstream rejected the key with
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError - Unexpected HTTP status from an upstream
+type StatusError struct {
+	Host       string
+	StatusCode int
+	Status     string
+	Err        error // ErrRateLimited or ErrUnauthorized when the status says so
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %s", e.Host, e.Status, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Host, e.Status)
+}
+
+// Unwrap - Returns the sentinel error of the status
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// CheckStatus - Returns a *StatusError when the response status isn't 2xx
+func CheckStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	e := &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+	if resp.Request != nil {
+		e.Host = resp.Request.URL.Hostname()
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		e.Err = ErrUnauthorized
+	case http.StatusTooManyRequests:
+		e.Err = ErrRateLimited
+	}
+
+	return e
+}
+
+// DecodeJSON - Unmarshals the upstream response body, wrapping failures with ErrSchemaChanged
+func DecodeJSON(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaChanged, err)
+	}
+
+	return nil
+}
+
+// ErrorKind - Returns the kind of upstream failure for counting: symbolNotFound for symbols to skip, otherwise
+// rateLimited, unauthorized, schemaChanged, circuitOpen or unavailable for a broken provider
+func ErrorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrSymbolNotFound):
+		return "symbolNotFound"
+	case errors.Is(err, ErrRateLimited):
+		return "rateLimited"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrSchemaChanged):
+		return "schemaChanged"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuitOpen"
+	}
+
+	return "unavailable"
+}
@@ -1,46 +1,82 @@
 package market
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
-	"time"
+	"strconv"
+	"strings"
 )
 
-var (
-	financialModelingPrepAPIKey string
-)
+// financialModelingPrepKeys - FIN_MODELING_API_KEYS, or the FIN_MODELING_API_KEY and FIN_MODELING_API_KEY_BACKUP
+// pair, rotated once FIN_MODELING_DAILY_LIMIT requests were made with a key or it's rate limited
+var financialModelingPrepKeys *KeyPool
 
 func init() {
-	financialModelingPrepAPIKey = os.Getenv("FIN_MODELING_API_KEY")
-
-	// Check if the key should be rotated out to avoid api rate limit later in the day
-	now := time.Now().UTC()
-	apiKeyRotationTime := time.Date(now.Year(), now.Month(), now.Day(), 18, 30, 0, 0, time.UTC)
-	if now.After(apiKeyRotationTime) {
-		log.Println("ApiKey rotation time, using FIN_MODELING_API_KEY_BACKUP")
-		financialModelingPrepAPIKey = os.Getenv("FIN_MODELING_API_KEY_BACKUP")
+	limit, err := strconv.Atoi(os.Getenv("FIN_MODELING_DAILY_LIMIT"))
+	if err != nil {
+		limit = 250 // free plan
 	}
+	financialModelingPrepKeys = NewKeyPool("financialModelingPrep", KeysFromEnv("FIN_MODELING_API_KEYS", "FIN_MODELING_API_KEY", "FIN_MODELING_API_KEY_BACKUP"), limit, QuotaDaily)
 }
 
-type financialModelingPrep struct{}
+type financialModelingPrep struct {
+	Upstream
+	Keys *KeyPool
+}
+
+// NewFinancialModelingPrep - Returns the Financial Modeling Prep TopMoversProvider calling the upstream with the
+// pool's keys
+func NewFinancialModelingPrep(u Upstream, keys *KeyPool) TopMoversProvider {
+	return &financialModelingPrep{
+		Upstream: u,
+		Keys:     keys,
+	}
+}
 
 type tickerResponse struct {
 	Ticker string `json:"ticker"`
 }
 
-// GetTopMovers - Implementation of the TopMoversProvider interface
-func (f *financialModelingPrep) GetTopMovers() ([]string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://financialmodelingprep.com/api/v3/gainers?apikey=%s", financialModelingPrepAPIKey))
-	if err != nil {
-		return nil, err
+type errorResponse struct {
+	Message string `json:"Error Message"`
+}
+
+// err - Returns the sentinel error of the message, e.g. "Limit Reach . Please upgrade your plan..." or
+// "Invalid API KEY...", nil for other messages
+func (e errorResponse) err() error {
+	message := strings.ToLower(e.Message)
+	switch {
+	case strings.Contains(message, "limit"):
+		return ErrRateLimited
+	case strings.Contains(message, "api key"):
+		return ErrUnauthorized
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	return nil
+}
+
+// Name - Implementation of the TopMoversProvider interface
+func (f *financialModelingPrep) Name() string {
+	return "financialModelingPrep"
+}
+
+// GetTopMovers - Implementation of the TopMoversProvider interface, retried with the next key in the pool when a key
+// is rejected
+func (f *financialModelingPrep) GetTopMovers(direction Direction) ([]string, error) {
+	var symbols []string
+	err := f.Keys.Do(func(key string) error {
+		var err error
+		symbols, err = f.getTopMovers(direction, key)
+		return err
+	})
+
+	return symbols, err
+}
+
+// getTopMovers - Returns the top movers in the direction using the api key
+func (f *financialModelingPrep) getTopMovers(direction Direction, key string) ([]string, error) {
+	resp, body, err := f.Get(fmt.Sprintf("/api/v3/%s?apikey=%s", direction, key))
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +84,22 @@ func (f *financialModelingPrep) GetTopMovers() ([]string, error) {
 	a := string(body)
 	log.Print(a)
 
+	// quota and key errors come back as an object, sometimes with a 200
+	var e errorResponse
+	if DecodeJSON(body, &e) == nil && e.Message != "" {
+		if err = e.err(); err != nil {
+			return nil, &KeyError{Err: fmt.Errorf("financialModelingPrep: %s: %w", e.Message, err)}
+		}
+		return nil, fmt.Errorf("financialModelingPrep: %s", e.Message)
+	}
+	if err = CheckStatus(resp); err != nil {
+		return nil, err
+	}
+
 	var r []tickerResponse
-	json.Unmarshal(body, &r)
+	if err = DecodeJSON(body, &r); err != nil {
+		return nil, fmt.Errorf("financialModelingPrep %s: %w", direction, err)
+	}
 	log.Println(r)
 
 	var symbols []string
@@ -1,16 +1,94 @@
 package market
 
-import "testing"
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
 
 func TestGetTopMovers_Success_ReturnsTopMovers(t *testing.T) {
-	f := &financialModelingPrep{}
-	r, err := f.GetTopMovers()
+	server, u := fixtureServer(t, FinancialModelingPrepURL, map[string]fixture{
+		"/api/v3/gainers?apikey=primary": {File: "fmp_gainers.json"},
+	})
+	defer server.Close()
+	f := NewFinancialModelingPrep(u, testKeyPool("primary", "backup"))
+	r, err := f.GetTopMovers(Gainers)
 
 	if err != nil {
 		t.Fatalf("Failed with unexpected error: %s", err)
 	}
 
-	if r == nil {
-		t.Fatalf("Failed with unexpected nil response: %v", r)
+	if len(r) != 2 || r[0] != "GNOG" {
+		t.Fatalf("Failed with unexpected response: %v", r)
+	}
+}
+
+func TestGetTopMovers_KeyRateLimited_RotatesToNextKey(t *testing.T) {
+	server, u := fixtureServer(t, FinancialModelingPrepURL, map[string]fixture{
+		"/api/v3/gainers?apikey=primary": {File: "fmp_limit_reached.json", Status: http.StatusTooManyRequests},
+		"/api/v3/gainers?apikey=backup":  {File: "fmp_gainers.json"},
+	})
+	defer server.Close()
+	keys := testKeyPool("primary", "backup")
+	f := NewFinancialModelingPrep(u, keys)
+	r, err := f.GetTopMovers(Gainers)
+
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if len(r) != 2 {
+		t.Fatalf("Failed with unexpected response: %v", r)
+	}
+
+	if k, err := keys.Key(); err != nil || k != "backup" {
+		t.Fatalf("Failed with unexpected key after rotation: %s %v", k, err)
+	}
+}
+
+func TestGetTopMovers_TooManyRequests_SkipsKeyForThisCall(t *testing.T) {
+	server, u := fixtureServer(t, FinancialModelingPrepURL, map[string]fixture{
+		"/api/v3/gainers?apikey=primary": {File: "fmp_too_many_requests.json", Status: http.StatusTooManyRequests},
+		"/api/v3/gainers?apikey=backup":  {File: "fmp_gainers.json"},
+	})
+	defer server.Close()
+	keys := testKeyPool("primary", "backup")
+	f := NewFinancialModelingPrep(u, keys)
+	r, err := f.GetTopMovers(Gainers)
+
+	if err != nil || len(r) != 2 {
+		t.Fatalf("Failed with unexpected response: %v %v", r, err)
+	}
+
+	// a 429 without FMP's quota message isn't saved as the key's quota running out
+	if k, err := keys.Key(); err != nil || k != "primary" {
+		t.Fatalf("Failed with unexpected key after a 429: %s %v", k, err)
+	}
+}
+
+func TestGetTopMovers_ErrorMessage_ReturnsTypedError(t *testing.T) {
+	server, u := fixtureServer(t, FinancialModelingPrepURL, map[string]fixture{
+		"/api/v3/gainers?apikey=exhausted": {File: "fmp_limit_reached.json", Status: http.StatusTooManyRequests},
+		"/api/v3/gainers?apikey=invalid":   {File: "fmp_invalid_key.json", Status: http.StatusUnauthorized},
+		"/api/v3/losers?apikey=exhausted":  {File: "fmp_limit_reached.json"},
+	})
+	defer server.Close()
+
+	tcs := []struct {
+		apiKey    string
+		direction Direction
+		expected  error
+	}{
+		{"exhausted", Gainers, ErrRateLimited},
+		{"invalid", Gainers, ErrUnauthorized},
+		{"exhausted", Losers, ErrRateLimited}, // quota errors can come back with a 200
+	}
+	for _, tc := range tcs {
+		f := NewFinancialModelingPrep(u, testKeyPool(tc.apiKey))
+		r, err := f.GetTopMovers(tc.direction)
+
+		if !errors.Is(err, tc.expected) || r != nil {
+			t.Fatalf("Failed with unexpected response for %s: %v %v", tc.apiKey, r, err)
+		}
 	}
 }
@@ -0,0 +1,91 @@
+package market
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DailyClose - Closing price of a single trading day
+type DailyClose struct {
+	Date  time.Time
+	Close float64
+}
+
+// PriceHistoryProvider - Provides historical daily closes for a symbol
+type PriceHistoryProvider interface {
+	GetDailyCloses(symbol string, from time.Time, to time.Time) ([]DailyClose, error)
+}
+
+// NewYahooPriceHistory - Returns a PriceHistoryProvider backed by Yahoo's chart API
+func NewYahooPriceHistory() PriceHistoryProvider {
+	return &yahooPriceHistory{
+		Upstream: NewUpstream(YahooChartURL),
+	}
+}
+
+type yahooPriceHistory struct {
+	Upstream
+}
+
+type chartResponse struct {
+	Chart struct {
+		Result []struct {
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Close []*float64 `json:"close"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error interface{} `json:"error"`
+	} `json:"chart"`
+}
+
+// GetDailyCloses - Implementation of the PriceHistoryProvider interface, closes are returned oldest first
+func (y *yahooPriceHistory) GetDailyCloses(symbol string, from time.Time, to time.Time) ([]DailyClose, error) {
+	resp, body, err := y.Get(fmt.Sprintf("/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d", symbol, from.Unix(), to.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+	}
+	if err = CheckStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var c chartResponse
+	if err = DecodeJSON(body, &c); err != nil {
+		return nil, fmt.Errorf("%s chart: %w", symbol, err)
+	}
+	if c.Chart.Error != nil {
+		return nil, fmt.Errorf("%s: %v", symbol, c.Chart.Error)
+	}
+
+	return parseChart(c), nil
+}
+
+// parseChart - Pairs the chart timestamps with their closes, skipping days without a close
+func parseChart(c chartResponse) []DailyClose {
+	var closes []DailyClose
+	for _, r := range c.Chart.Result {
+		if len(r.Indicators.Quote) == 0 {
+			continue
+		}
+
+		quote := r.Indicators.Quote[0]
+		for i, ts := range r.Timestamp {
+			if i >= len(quote.Close) || quote.Close[i] == nil {
+				continue
+			}
+
+			closes = append(closes, DailyClose{
+				Date:  time.Unix(ts, 0).UTC(),
+				Close: *quote.Close[i],
+			})
+		}
+	}
+
+	return closes
+}
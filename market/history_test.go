@@ -0,0 +1,25 @@
+package market
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseChart_MissingClose_SkipsDay(t *testing.T) {
+	body := `{"chart":{"result":[{"timestamp":[1609770600,1609857000,1609943400],"indicators":{"quote":[{"close":[10.5,null,12.25]}]}}],"error":null}}`
+
+	var c chartResponse
+	if err := json.Unmarshal([]byte(body), &c); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	closes := parseChart(c)
+
+	if len(closes) != 2 || closes[0].Close != 10.5 || closes[1].Close != 12.25 {
+		t.Fatalf("Failed with unexpected response: %v", closes)
+	}
+	if !closes[1].Date.Equal(time.Unix(1609943400, 0)) {
+		t.Fatalf("Failed with unexpected date: %v", closes[1].Date)
+	}
+}
@@ -0,0 +1,271 @@
+package market
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota - Window an upstream's request quota resets on
+type Quota string
+
+const (
+	// QuotaDaily - Quota resets at midnight UTC
+	QuotaDaily Quota = "daily"
+	// QuotaMonthly - Quota resets on the first of the month UTC
+	QuotaMonthly Quota = "monthly"
+)
+
+// window - Returns the quota window t falls in
+func (q Quota) window(t time.Time) string {
+	if q == QuotaMonthly {
+		return t.UTC().Format("2006-01")
+	}
+
+	return t.UTC().Format("2006-01-02")
+}
+
+// KeyStore - Persists API key usage per quota window so every container rotates keys the same way, e.g. a
+// data.KeyStore
+type KeyStore interface {
+	// UseKey - Counts a request made with the key unless it's exhausted, and returns the requests counted and
+	// whether it's exhausted
+	UseKey(id string) (int, bool, error)
+	// ExhaustKey - Marks the key exhausted
+	ExhaustKey(id string) error
+}
+
+var (
+	keyStoreMu sync.Mutex
+	keyStore   KeyStore
+)
+
+// SetKeyStore - Persists the usage of every KeyPool without its own Store
+func SetKeyStore(s KeyStore) {
+	keyStoreMu.Lock()
+	defer keyStoreMu.Unlock()
+
+	keyStore = s
+}
+
+// KeysFromEnv - Returns the comma separated keys in the environment variables, in order and without duplicates
+func KeysFromEnv(variables ...string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, v := range variables {
+		for _, k := range strings.Split(os.Getenv(v), ",") {
+			k = strings.TrimSpace(k)
+			if k == "" || seen[k] {
+				continue
+			}
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// keyUsage - Usage of a key tracked in process memory
+type keyUsage struct {
+	requests  int
+	exhausted bool
+}
+
+// KeyPool - API keys of an upstream used in order, rotating to the next key once one is exhausted for the quota
+// window
+type KeyPool struct {
+	Name  string
+	Keys  []string
+	Limit int      // requests per key and window, 0 rotates only when the upstream reports the key exhausted
+	Quota Quota    // defaults to daily
+	Store KeyStore // defaults to the SetKeyStore store, then process memory
+	Now   func() time.Time
+
+	mu    sync.Mutex
+	local map[string]*keyUsage
+}
+
+// NewKeyPool - Public constructor for KeyPool
+func NewKeyPool(name string, keys []string, limit int, quota Quota) *KeyPool {
+	return &KeyPool{
+		Name:  name,
+		Keys:  keys,
+		Limit: limit,
+		Quota: quota,
+		Now:   time.Now,
+		local: make(map[string]*keyUsage),
+	}
+}
+
+// Key - Returns the first key that isn't exhausted in the current window and counts a request against it. Returns
+// an empty key when the pool has none, and ErrRateLimited when every key is exhausted.
+func (p *KeyPool) Key() (string, error) {
+	return p.key(nil)
+}
+
+// Do - Calls f with a key from the pool, moving on to the next key when the upstream rejects it. A *KeyError
+// exhausts the key until its quota resets, any other ErrRateLimited or ErrUnauthorized, e.g. a brief 429, only skips
+// it for this call. Returns the last rejection once every key was tried.
+func (p *KeyPool) Do(f func(key string) error) error {
+	var rejected error
+	skip := make(map[string]bool)
+	for {
+		key, err := p.key(skip)
+		if err != nil && rejected != nil {
+			return rejected // every key was tried, report why the last one was rejected
+		}
+		if err != nil {
+			return err
+		}
+
+		err = f(key)
+		var keyErr *KeyError
+		switch {
+		case key == "":
+			return err
+		case errors.As(err, &keyErr):
+			log.Println(err)
+			p.Exhaust(key)
+		case errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnauthorized):
+			log.Printf("%s: key %s skipped for this call: %s", p.Name, fingerprint(key), err)
+			skip[key] = true
+		default:
+			return err
+		}
+		rejected = err
+	}
+}
+
+// key - Returns the first key that isn't skipped or exhausted in the current window and counts a request against it
+func (p *KeyPool) key(skip map[string]bool) (string, error) {
+	if len(p.Keys) == 0 {
+		return "", nil
+	}
+
+	window := p.Quota.window(p.now())
+	for _, k := range p.Keys {
+		if skip[k] {
+			continue
+		}
+		id := p.id(k, window)
+		requests, exhausted := p.use(id)
+		if exhausted {
+			continue
+		}
+		if p.Limit > 0 && requests >= p.Limit {
+			// the key's last request in the window, later calls skip it without counting against it
+			p.exhaust(id)
+		}
+		if p.Limit > 0 && requests > p.Limit {
+			continue // other containers used up the key at the same time
+		}
+
+		return k, nil
+	}
+
+	return "", fmt.Errorf("%s: all %d keys exhausted until the %s quota resets: %w", p.Name, len(p.Keys), p.Quota.name(), ErrRateLimited)
+}
+
+// Exhaust - Marks the key exhausted for the rest of the window, called when the upstream rejects it
+func (p *KeyPool) Exhaust(key string) {
+	log.Printf("%s: key %s exhausted, rotating", p.Name, fingerprint(key))
+	p.exhaust(p.id(key, p.Quota.window(p.now())))
+}
+
+// exhaust - Marks the key id exhausted in the store, falling back to process memory
+func (p *KeyPool) exhaust(id string) {
+	if s := p.store(); s != nil {
+		err := s.ExhaustKey(id)
+		if err == nil {
+			return
+		}
+		log.Println(err) // fall back to tracking the key in memory
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.usage(id).exhausted = true
+}
+
+// use - Counts a request against the key id unless it's exhausted, and returns its usage
+func (p *KeyPool) use(id string) (int, bool) {
+	if s := p.store(); s != nil {
+		requests, exhausted, err := s.UseKey(id)
+		if err == nil {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			// keys exhausted while the store was failing stay exhausted
+			return requests, exhausted || p.usage(id).exhausted
+		}
+		log.Println(err) // fall back to tracking the key in memory
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	u := p.usage(id)
+	if !u.exhausted {
+		u.requests++
+	}
+
+	return u.requests, u.exhausted
+}
+
+// usage - Returns the in-memory usage of the key id, the caller holds the lock
+func (p *KeyPool) usage(id string) *keyUsage {
+	if p.local == nil {
+		p.local = make(map[string]*keyUsage)
+	}
+	u, ok := p.local[id]
+	if !ok {
+		u = &keyUsage{}
+		p.local[id] = u
+	}
+
+	return u
+}
+
+func (p *KeyPool) store() KeyStore {
+	if p.Store != nil {
+		return p.Store
+	}
+
+	keyStoreMu.Lock()
+	defer keyStoreMu.Unlock()
+
+	return keyStore
+}
+
+// id - Returns the usage id of the key in the window, keys are stored as a fingerprint
+func (p *KeyPool) id(key string, window string) string {
+	return strings.Join([]string{p.Name, fingerprint(key), window}, "#")
+}
+
+// fingerprint - Returns a short hash identifying the key in logs and the store without revealing it
+func fingerprint(key string) string {
+	h := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(h[:])[:12]
+}
+
+func (p *KeyPool) now() time.Time {
+	if p.Now == nil {
+		return time.Now()
+	}
+
+	return p.Now()
+}
+
+func (q Quota) name() string {
+	if q == "" {
+		return string(QuotaDaily)
+	}
+
+	return string(q)
+}
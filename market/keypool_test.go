@@ -0,0 +1,158 @@
+package market
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeyPool - Returns a daily pool of the keys tracked in memory, with its clock fixed
+func testKeyPool(keys ...string) *KeyPool {
+	p := NewKeyPool("test", keys, 0, QuotaDaily)
+	p.Now = func() time.Time { return time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC) }
+
+	return p
+}
+
+// memoryKeyStore - KeyStore shared by pools in the test, as containers share the data store
+type memoryKeyStore map[string]*keyUsage
+
+func (m memoryKeyStore) UseKey(id string) (int, bool, error) {
+	u, ok := m[id]
+	if !ok {
+		u = &keyUsage{}
+		m[id] = u
+	}
+	if !u.exhausted {
+		u.requests++
+	}
+
+	return u.requests, u.exhausted, nil
+}
+
+func (m memoryKeyStore) ExhaustKey(id string) error {
+	u, ok := m[id]
+	if !ok {
+		u = &keyUsage{}
+		m[id] = u
+	}
+	u.exhausted = true
+
+	return nil
+}
+
+func TestKeyPool_Key_RotatesAtLimit(t *testing.T) {
+	p := testKeyPool("primary", "backup")
+	p.Limit = 2
+
+	var keys []string
+	for i := 0; i < 4; i++ {
+		k, err := p.Key()
+		if err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+		keys = append(keys, k)
+	}
+
+	expected := []string{"primary", "primary", "backup", "backup"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("Failed with unexpected keys: %v", keys)
+	}
+
+	if k, err := p.Key(); !errors.Is(err, ErrRateLimited) || k != "" {
+		t.Fatalf("Failed with unexpected response: %s %v", k, err)
+	}
+
+	for id, u := range p.local {
+		if u.requests != 2 || !u.exhausted {
+			t.Fatalf("Failed with unexpected usage of %s: %v", id, *u)
+		}
+	}
+}
+
+func TestKeyPool_Exhaust_RotatesUntilQuotaResets(t *testing.T) {
+	now := time.Date(2021, 1, 4, 23, 0, 0, 0, time.UTC)
+	p := testKeyPool("primary", "backup")
+	p.Now = func() time.Time { return now }
+
+	p.Exhaust("primary")
+	if k, err := p.Key(); err != nil || k != "backup" {
+		t.Fatalf("Failed with unexpected key: %s %v", k, err)
+	}
+
+	p.Exhaust("backup")
+	if _, err := p.Key(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Failed with unexpected error: %v", err)
+	}
+
+	now = now.Add(2 * time.Hour) // next UTC day
+	if k, err := p.Key(); err != nil || k != "primary" {
+		t.Fatalf("Failed with unexpected key after the quota reset: %s %v", k, err)
+	}
+}
+
+func TestKeyPool_MonthlyQuota_ResetsOnFirstOfMonth(t *testing.T) {
+	now := time.Date(2021, 1, 31, 23, 0, 0, 0, time.UTC)
+	p := NewKeyPool("test", []string{"primary"}, 0, QuotaMonthly)
+	p.Now = func() time.Time { return now }
+
+	p.Exhaust("primary")
+	now = now.Add(-20 * 24 * time.Hour) // same month
+	if _, err := p.Key(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Failed with unexpected error: %v", err)
+	}
+
+	now = time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	if k, err := p.Key(); err != nil || k != "primary" {
+		t.Fatalf("Failed with unexpected key after the quota reset: %s %v", k, err)
+	}
+}
+
+func TestKeyPool_Store_SharesExhaustionAcrossPools(t *testing.T) {
+	store := memoryKeyStore{}
+	SetKeyStore(store)
+	defer SetKeyStore(nil)
+
+	first := testKeyPool("primary", "backup")
+	second := testKeyPool("primary", "backup")
+
+	first.Exhaust("primary")
+	if k, err := second.Key(); err != nil || k != "backup" {
+		t.Fatalf("Failed with unexpected key: %s %v", k, err)
+	}
+
+	for id, u := range store {
+		if strings.Contains(id, "primary") {
+			t.Fatalf("Failed with the key stored in plain text: %s", id)
+		}
+		if u.exhausted && u.requests != 0 {
+			t.Fatalf("Failed with requests counted against an exhausted key: %d", u.requests)
+		}
+	}
+}
+
+func TestKeyPool_Key_NoKeys_ReturnsEmptyKey(t *testing.T) {
+	k, err := testKeyPool().Key()
+
+	if err != nil || k != "" {
+		t.Fatalf("Failed with unexpected response: %s %v", k, err)
+	}
+}
+
+func TestKeysFromEnv_ReturnsKeysInOrder(t *testing.T) {
+	os.Setenv("TEST_API_KEYS", "a, b,,c")
+	os.Setenv("TEST_API_KEY", "a")
+	os.Setenv("TEST_API_KEY_BACKUP", "d")
+	defer os.Unsetenv("TEST_API_KEYS")
+	defer os.Unsetenv("TEST_API_KEY")
+	defer os.Unsetenv("TEST_API_KEY_BACKUP")
+
+	keys := KeysFromEnv("TEST_API_KEYS", "TEST_API_KEY", "TEST_API_KEY_BACKUP")
+
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("Failed with unexpected keys: %v", keys)
+	}
+}
@@ -1,37 +1,55 @@
 package market
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
 	"regexp"
 	"strings"
 )
 
 var (
-	newsAPIKey          string
 	companySuffixRegexp *regexp.Regexp
 )
 
 func init() {
-	newsAPIKey = os.Getenv("NEWS_API_KEY")
 	companySuffixRegexp = regexp.MustCompile(`(?i)inc\.|(?i)Incorporated|(?i)plc|(?i)corporation|(?i)corp\.|(?i)limited|(?i)ltd\.`)
 }
 
-// TopMoversProvider - Provides a list of "top mover" stock symbols for the day
+// Direction - Direction of a top mover's price change
+type Direction string
+
+const (
+	// Gainers - Top movers with the largest price increase
+	Gainers Direction = "gainers"
+	// Losers - Top movers with the largest price decrease
+	Losers Direction = "losers"
+)
+
+// ParseDirection - Parses a Direction, defaulting to Gainers when empty
+func ParseDirection(s string) (Direction, error) {
+	switch Direction(s) {
+	case "", Gainers:
+		return Gainers, nil
+	case Losers:
+		return Losers, nil
+	}
+
+	return "", fmt.Errorf("unknown direction %q", s)
+}
+
+// TopMoversProvider - Provides a list of "top mover" stock symbols for the day in the given direction
 type TopMoversProvider interface {
-	GetTopMovers() ([]string, error)
+	Name() string
+	GetTopMovers(direction Direction) ([]string, error)
 }
 
 // GetTopMoversProviders - Returns a collection of TopMoversProvider
 func GetTopMoversProviders() []TopMoversProvider {
 	return []TopMoversProvider{
-		&robinhood{},
-		&financialModelingPrep{},
+		NewRobinhood(NewUpstream(RobinhoodURL)),
+		NewFinancialModelingPrep(NewUpstream(FinancialModelingPrepURL), financialModelingPrepKeys),
 	}
 }
 
@@ -45,6 +63,44 @@ type RecommendationRating struct {
 	StrongSell int64  `json:"strongSell"`
 }
 
+// Trend - Analyst recommendation ratings per period as returned by Yahoo, most recent ("0m") first
+type Trend []RecommendationRating
+
+// TrendChange - Change in analyst ratings between two periods
+type TrendChange struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Bullish int64  `json:"bullish"` // change in strongBuy+buy
+	Bearish int64  `json:"bearish"` // change in sell+strongSell
+}
+
+// NetUpgrades - Bullish rating gains plus bearish rating losses
+func (c TrendChange) NetUpgrades() int64 {
+	return c.Bullish - c.Bearish
+}
+
+// Change - Returns the change in ratings from the previous period to the current one, or false when there's
+// less than two periods of history
+func (t Trend) Change() (TrendChange, bool) {
+	return t.ChangeSince(1)
+}
+
+// ChangeSince - Returns the change in ratings from the period n months ago to the current one, or false when
+// the trend doesn't go back that far
+func (t Trend) ChangeSince(months int) (TrendChange, bool) {
+	if months < 1 || len(t) <= months {
+		return TrendChange{}, false
+	}
+
+	current, previous := t[0], t[months]
+	return TrendChange{
+		From:    previous.Period,
+		To:      current.Period,
+		Bullish: (current.StrongBuy + current.Buy) - (previous.StrongBuy + previous.Buy),
+		Bearish: (current.Sell + current.StrongSell) - (previous.Sell + previous.StrongSell),
+	}, true
+}
+
 // Currency - Currency data
 type Currency struct {
 	USD float64 `json:"raw"`
@@ -69,11 +125,19 @@ type Price struct {
 	PreMarketPrice Currency `json:"preMarketPrice"`
 }
 
+// Analysis - Price, analyst recommendation and financial data for a symbol
+type Analysis struct {
+	Price         Price
+	Rating        RecommendationRating // current period ("0m") of Trend
+	Trend         Trend
+	FinancialData FinancialData
+}
+
 type quoteResponse struct {
 	Summary struct {
 		Result []struct {
 			RecommendationTrend struct {
-				Trend []RecommendationRating `json:"trend"`
+				Trend Trend `json:"trend"`
 			} `json:"recommendationTrend"`
 			Price         Price         `json:"price"`
 			FinancialData FinancialData `json:"financialData"`
@@ -82,50 +146,77 @@ type quoteResponse struct {
 	} `json:"quoteSummary"`
 }
 
-// GetAnalysis - Calculates the gain percentage from previous close to current and fetches analyst recommendation rating
-func GetAnalysis(symbol string) (Price, RecommendationRating, FinancialData, error) {
-	var rating RecommendationRating
-	var data FinancialData
-	var price Price
+type yahoo struct {
+	Quote        Upstream
+	Autocomplete Upstream
+}
 
-	resp, err := http.Get(fmt.Sprintf("https://query2.finance.yahoo.com/v10/finance/quoteSummary/%s?region=US&modules=recommendationTrend%%2Cprice%%2CfinancialData", symbol))
-	if err != nil {
-		return price, rating, data, err
+// NewYahoo - Returns the Yahoo Finance client calling the quote summary and autocomplete upstreams
+func NewYahoo(quote Upstream, autocomplete Upstream) *yahoo {
+	return &yahoo{
+		Quote:        quote,
+		Autocomplete: autocomplete,
 	}
-	defer resp.Body.Close()
+}
+
+var defaultYahoo = NewYahoo(NewUpstream(YahooQuoteURL), NewUpstream(YahooAutocompleteURL))
 
-	body, err := ioutil.ReadAll(resp.Body)
+// GetAnalysis - Calculates the gain percentage from previous close to current and fetches the analyst recommendation trend
+func GetAnalysis(symbol string) (Analysis, error) {
+	return defaultYahoo.GetAnalysis(symbol)
+}
+
+// GetCompanyName - Gets the company name that the symbol is associated with
+func GetCompanyName(symbol string) (string, error) {
+	return defaultYahoo.GetCompanyName(symbol)
+}
+
+// GetAnalysis - Calculates the gain percentage from previous close to current and fetches the analyst recommendation
+// trend, ErrSymbolNotFound for unknown symbols
+func (y *yahoo) GetAnalysis(symbol string) (Analysis, error) {
+	var a Analysis
+
+	resp, body, err := y.Quote.Get(fmt.Sprintf("/v10/finance/quoteSummary/%s?region=US&modules=recommendationTrend%%2Cprice%%2CfinancialData", symbol))
 	if err != nil {
-		return price, rating, data, err
+		return a, err
 	}
 
-	if resp.StatusCode == 404 {
-		log.Printf("Symbol not found:%s", symbol)
-		return price, rating, data, err
+	if resp.StatusCode == http.StatusNotFound {
+		return a, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+	}
+	if err = CheckStatus(resp); err != nil {
+		return a, err
 	}
 
 	var q quoteResponse
-	json.Unmarshal(body, &q)
+	if err = DecodeJSON(body, &q); err != nil {
+		return a, fmt.Errorf("%s quote summary: %w", symbol, err)
+	}
 	log.Println(q)
 
 	if q.Summary.Error != nil {
-		return price, rating, data, fmt.Errorf("%v", q.Summary.Error)
+		return a, fmt.Errorf("%s: %v", symbol, q.Summary.Error)
+	}
+	if q.Summary.Result == nil {
+		return a, fmt.Errorf("%s quote summary without a result or error: %w", symbol, ErrSchemaChanged)
 	}
 	if len(q.Summary.Result) < 1 {
-		return price, rating, data, nil
+		return a, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
 	}
 	result := q.Summary.Result[0]
 
 	result.Price.MarketChange.Percent = result.Price.MarketChange.Percent * 100
-	data.CurrentPrice = result.FinancialData.CurrentPrice
+	a.Price = result.Price
+	a.FinancialData.CurrentPrice = result.FinancialData.CurrentPrice
 	if len(result.RecommendationTrend.Trend) > 0 {
-		rating = result.RecommendationTrend.Trend[0]
-		data.TargetLowPrice = result.FinancialData.TargetLowPrice
-		data.TargetHighPrice = result.FinancialData.TargetHighPrice
-		data.TargetMeanPrice = result.FinancialData.TargetMeanPrice
+		a.Trend = result.RecommendationTrend.Trend
+		a.Rating = a.Trend[0]
+		a.FinancialData.TargetLowPrice = result.FinancialData.TargetLowPrice
+		a.FinancialData.TargetHighPrice = result.FinancialData.TargetHighPrice
+		a.FinancialData.TargetMeanPrice = result.FinancialData.TargetMeanPrice
 	}
 
-	return result.Price, rating, data, nil
+	return a, nil
 }
 
 type companyResponse struct {
@@ -138,20 +229,19 @@ type companyResponse struct {
 }
 
 // GetCompanyName - Gets the company name that the symbol is associated with
-func GetCompanyName(symbol string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://autoc.finance.yahoo.com/autoc?lang=en&query=%s", symbol))
+func (y *yahoo) GetCompanyName(symbol string) (string, error) {
+	resp, body, err := y.Autocomplete.Get(fmt.Sprintf("/autoc?lang=en&query=%s", symbol))
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	if err = CheckStatus(resp); err != nil {
 		return "", err
 	}
 
 	var c companyResponse
-	json.Unmarshal(body, &c)
+	if err = DecodeJSON(body, &c); err != nil {
+		return "", fmt.Errorf("%s company name: %w", symbol, err)
+	}
 	log.Println(c)
 
 	for _, v := range c.ResultSet.Result {
@@ -1,16 +1,46 @@
 package market
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func yahooFixtures(t *testing.T) (*yahoo, func()) {
+	quoteServer, quote := fixtureServer(t, YahooQuoteURL, map[string]fixture{
+		"/v10/finance/quoteSummary/FB?region=US&modules=recommendationTrend%2Cprice%2CfinancialData":           {File: "yahoo_quote_summary_FB.json"},
+		"/v10/finance/quoteSummary/NOT_A_SYMBOL?region=US&modules=recommendationTrend%2Cprice%2CfinancialData": {File: "yahoo_quote_summary_not_found.json", Status: http.StatusNotFound},
+		"/v10/finance/quoteSummary/GNOG?region=US&modules=recommendationTrend%2Cprice%2CfinancialData":         {File: "yahoo_quote_summary_unexpected.json"},
+		"/v10/finance/quoteSummary/MOON?region=US&modules=recommendationTrend%2Cprice%2CfinancialData":         {File: "yahoo_unauthorized.json", Status: http.StatusUnauthorized},
+	})
+	autocompleteServer, autocomplete := fixtureServer(t, YahooAutocompleteURL, map[string]fixture{
+		"/autoc?lang=en&query=FB":           {File: "yahoo_autocomplete_FB.json"},
+		"/autoc?lang=en&query=NOT_A_SYMBOL": {File: "yahoo_autocomplete_not_found.json"},
+	})
+
+	return NewYahoo(quote, autocomplete), func() {
+		quoteServer.Close()
+		autocompleteServer.Close()
+	}
+}
 
 func TestGetAnalysis_KnownSymbol_ReturnsGainAndRating(t *testing.T) {
+	y, done := yahooFixtures(t)
+	defer done()
 	symbol := "FB"
 
-	price, rating, data, err := GetAnalysis(symbol)
+	a, err := y.GetAnalysis(symbol)
+	price, rating, data := a.Price, a.Rating, a.FinancialData
 
 	if err != nil {
 		t.Fatalf("Failed with unexpected error: %s", err)
 	}
 
+	if len(a.Trend) < 2 {
+		t.Fatalf("Failed with unexpected trend response: %v", a.Trend)
+	}
+
 	if price.MarketChange.Percent == 0 || price.PreMarketPrice.USD == 0 {
 		t.Fatalf("Failed with unexpected response: %.2f", price)
 	}
@@ -24,13 +54,16 @@ func TestGetAnalysis_KnownSymbol_ReturnsGainAndRating(t *testing.T) {
 	}
 }
 
-func TestGetAnalysis_UnknownSymbol_ReturnsEmptyResponse(t *testing.T) {
+func TestGetAnalysis_UnknownSymbol_ReturnsErrSymbolNotFound(t *testing.T) {
+	y, done := yahooFixtures(t)
+	defer done()
 	symbol := "NOT_A_SYMBOL"
 
-	price, rating, data, err := GetAnalysis(symbol)
+	a, err := y.GetAnalysis(symbol)
+	price, rating, data := a.Price, a.Rating, a.FinancialData
 
-	if err != nil {
-		t.Fatalf("Failed with unexpected error: %s", err)
+	if !errors.Is(err, ErrSymbolNotFound) {
+		t.Fatalf("Failed with unexpected error: %v", err)
 	}
 
 	if price.MarketChange.Percent != 0 || price.PreMarketPrice.USD != 0 {
@@ -46,10 +79,44 @@ func TestGetAnalysis_UnknownSymbol_ReturnsEmptyResponse(t *testing.T) {
 	}
 }
 
+func TestGetAnalysis_UpstreamFailures_ReturnTypedErrors(t *testing.T) {
+	y, done := yahooFixtures(t)
+	defer done()
+
+	if _, err := y.GetAnalysis("GNOG"); !errors.Is(err, ErrSchemaChanged) {
+		t.Fatalf("Failed with unexpected error for an unexpected body: %v", err)
+	}
+
+	_, err := y.GetAnalysis("MOON")
+	var statusErr *StatusError
+	if !errors.Is(err, ErrUnauthorized) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Failed with unexpected error for a 401: %v", err)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	tcs := map[string]error{
+		"symbolNotFound": fmt.Errorf("GNOG: %w", ErrSymbolNotFound),
+		"rateLimited":    &StatusError{StatusCode: http.StatusTooManyRequests, Err: ErrRateLimited},
+		"unauthorized":   fmt.Errorf("financialModelingPrep: Invalid API KEY: %w", ErrUnauthorized),
+		"schemaChanged":  fmt.Errorf("GNOG quote summary: %w", ErrSchemaChanged),
+		"circuitOpen":    fmt.Errorf("query2.finance.yahoo.com: %w", ErrCircuitOpen),
+		"unavailable":    &StatusError{StatusCode: http.StatusBadGateway},
+	}
+
+	for expected, err := range tcs {
+		if actual := ErrorKind(err); actual != expected {
+			t.Fatalf("Failed expected:%s actual:%s", expected, actual)
+		}
+	}
+}
+
 func TestGetCompanyName_KnownSymbol_ReturnsCompanyName(t *testing.T) {
+	y, done := yahooFixtures(t)
+	defer done()
 	symbol := "FB"
 
-	name, err := GetCompanyName(symbol)
+	name, err := y.GetCompanyName(symbol)
 
 	if err != nil {
 		t.Fatalf("Failed with unexpected error: %s", err)
@@ -61,9 +128,11 @@ func TestGetCompanyName_KnownSymbol_ReturnsCompanyName(t *testing.T) {
 }
 
 func TestGetCompanyName_UnknownSymbol_ReturnsEmptyResponse(t *testing.T) {
+	y, done := yahooFixtures(t)
+	defer done()
 	symbol := "NOT_A_SYMBOL"
 
-	name, err := GetCompanyName(symbol)
+	name, err := y.GetCompanyName(symbol)
 
 	if err != nil {
 		t.Fatalf("Failed with unexpected error: %s", err)
@@ -97,3 +166,50 @@ func TestGetNews_EmptyCompanyName_ReturnsError(t *testing.T) {
 		t.Fatalf("Failed with unexpected response: %s", uri)
 	}
 }
+
+func TestParseDirection(t *testing.T) {
+	tcs := map[string]Direction{
+		"":        Gainers,
+		"gainers": Gainers,
+		"losers":  Losers,
+	}
+
+	for s, expected := range tcs {
+		actual, err := ParseDirection(s)
+		if err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+		if actual != expected {
+			t.Fatalf("Failed expected:%s actual:%s", expected, actual)
+		}
+	}
+
+	if _, err := ParseDirection("sideways"); err == nil {
+		t.Fatal("Failed to reject unknown direction")
+	}
+}
+
+func TestTrendChange_Upgrades_ReturnsNetUpgrades(t *testing.T) {
+	trend := Trend{
+		{Period: "0m", StrongBuy: 3, Buy: 4, Hold: 2, Sell: 0},
+		{Period: "-1m", StrongBuy: 2, Buy: 3, Hold: 3, Sell: 1},
+		{Period: "-2m", StrongBuy: 1, Buy: 3, Hold: 3, Sell: 2},
+	}
+
+	c, ok := trend.Change()
+	if !ok {
+		t.Fatal("Failed with unexpected missing change")
+	}
+	if c.From != "-1m" || c.To != "0m" || c.Bullish != 2 || c.Bearish != -1 || c.NetUpgrades() != 3 {
+		t.Fatalf("Failed with unexpected response: %v", c)
+	}
+
+	c, ok = trend.ChangeSince(2)
+	if !ok || c.From != "-2m" || c.Bullish != 3 || c.Bearish != -2 {
+		t.Fatalf("Failed with unexpected response: %v", c)
+	}
+
+	if _, ok = trend.ChangeSince(3); ok {
+		t.Fatal("Failed with unexpected change beyond trend history")
+	}
+}
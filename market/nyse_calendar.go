@@ -0,0 +1,11 @@
+package market
+
+// nyseCalendar - Special NYSE closures the holiday rules don't cover, in the calendar file format. Kept in source so
+// every build loads it without shipping a file beside the binary.
+const nyseCalendar = `{
+  "holidays": [
+    {"date": "2018-12-05", "name": "National Day of Mourning for President George H.W. Bush"},
+    {"date": "2025-01-09", "name": "National Day of Mourning for President Jimmy Carter"}
+  ],
+  "earlyCloses": []
+}`
@@ -1,20 +1,38 @@
 package market
 
 import (
-	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 )
 
-type robinhood struct{}
+type robinhood struct {
+	Upstream
+}
+
+// NewRobinhood - Returns the Robinhood TopMoversProvider calling the upstream
+func NewRobinhood(u Upstream) TopMoversProvider {
+	return &robinhood{
+		Upstream: u,
+	}
+}
 
 type moversResponse struct {
 	InstrumentURIs []string `json:"instruments"`
 }
 
+// Name - Implementation of the TopMoversProvider interface
+func (r *robinhood) Name() string {
+	return "robinhood"
+}
+
 // GetTopMovers - Implementation of the TopMoversProvider interface
-func (r *robinhood) GetTopMovers() ([]string, error) {
+func (r *robinhood) GetTopMovers(direction Direction) ([]string, error) {
+	if direction == Losers {
+		return r.getSP500Movers("down")
+	}
+
 	urls, err := r.getTopMoversInstrumentIds()
 	if err != nil {
 		return nil, err
@@ -23,6 +41,10 @@ func (r *robinhood) GetTopMovers() ([]string, error) {
 	var symbols []string
 	for _, v := range urls {
 		symbol, err := r.getSymbol(v)
+		if errors.Is(err, ErrSymbolNotFound) {
+			log.Println(err) // log and continue with the other instruments
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -35,43 +57,74 @@ func (r *robinhood) GetTopMovers() ([]string, error) {
 
 // getTopMoversInstrumentIds - Returns a collection of URIs associated with Robinhood's "Top Movers" list
 func (r *robinhood) getTopMoversInstrumentIds() ([]string, error) {
-	resp, err := http.Get("https://api.robinhood.com/midlands/tags/tag/top-movers/")
+	resp, body, err := r.Get("/midlands/tags/tag/top-movers/")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	if err = CheckStatus(resp); err != nil {
 		return nil, err
 	}
 
 	var m moversResponse
-	json.Unmarshal(body, &m)
+	if err = DecodeJSON(body, &m); err != nil {
+		return nil, fmt.Errorf("robinhood top movers: %w", err)
+	}
 	log.Println(m)
 
 	return m.InstrumentURIs, nil
 }
 
+type sp500MoversResponse struct {
+	Results []struct {
+		Symbol string `json:"symbol"`
+	} `json:"results"`
+}
+
+// getSP500Movers - Returns the symbols of Robinhood's S&P 500 movers in the direction ("up" or "down")
+func (r *robinhood) getSP500Movers(direction string) ([]string, error) {
+	resp, body, err := r.Get(fmt.Sprintf("/midlands/movers/sp500/?direction=%s", direction))
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var m sp500MoversResponse
+	if err = DecodeJSON(body, &m); err != nil {
+		return nil, fmt.Errorf("robinhood sp500 movers: %w", err)
+	}
+	log.Println(m)
+
+	var symbols []string
+	for _, v := range m.Results {
+		symbols = append(symbols, v.Symbol)
+	}
+
+	return symbols, nil
+}
+
 type instrumentResponse struct {
 	Symbol string `json:"symbol"`
 }
 
-// getSymbol - Returns ticker symbol associated with the instrumentURI
+// getSymbol - Returns ticker symbol associated with the instrumentURI, ErrSymbolNotFound for unknown instruments
 func (r *robinhood) getSymbol(instrumentURI string) (string, error) {
-	resp, err := http.Get(instrumentURI)
+	resp, body, err := r.Get(instrumentURI)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("robinhood instrument %s: %w", instrumentURI, ErrSymbolNotFound)
+	}
+	if err = CheckStatus(resp); err != nil {
 		return "", err
 	}
 
 	var i instrumentResponse
-	json.Unmarshal(body, &i)
+	if err = DecodeJSON(body, &i); err != nil {
+		return "", fmt.Errorf("robinhood instrument %s: %w", instrumentURI, err)
+	}
 	log.Println(i)
 
 	return i.Symbol, nil
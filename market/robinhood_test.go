@@ -1,9 +1,25 @@
 package market
 
-import "testing"
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func robinhoodFixtures(t *testing.T) (*robinhood, func()) {
+	server, u := fixtureServer(t, RobinhoodURL, map[string]fixture{
+		"/midlands/tags/tag/top-movers/":                     {File: "robinhood_top_movers.json"},
+		"/instruments/6513270e-269e-4d37-b2a7-4de452e6b438/": {File: "robinhood_instrument.json"},
+		"/instruments/unknown-instrumentID/":                 {File: "robinhood_instrument_not_found.json", Status: http.StatusNotFound},
+		"/midlands/movers/sp500/?direction=down":             {File: "robinhood_sp500_movers_down.json"},
+	})
+
+	return &robinhood{Upstream: u}, server.Close
+}
 
 func TestTopMoversInstrumentIds_Success_ReturnsInstrumentURIs(t *testing.T) {
-	r := &robinhood{}
+	r, done := robinhoodFixtures(t)
+	defer done()
 	result, err := r.getTopMoversInstrumentIds()
 
 	if err != nil {
@@ -15,16 +31,50 @@ func TestTopMoversInstrumentIds_Success_ReturnsInstrumentURIs(t *testing.T) {
 	}
 }
 
-func TestGetSymbol_UnknownInstrumentURI_ReturnsEmptyString(t *testing.T) {
-	const instrumentURI string = "https://api.robinhood.com/instruments/unknown-instrumentID/"
-	r := &robinhood{}
-	result, err := r.getSymbol(instrumentURI)
+func TestGetSymbol_KnownInstrumentURI_ReturnsSymbol(t *testing.T) {
+	r, done := robinhoodFixtures(t)
+	defer done()
+	uris, err := r.getTopMoversInstrumentIds()
+	if err != nil || len(uris) == 0 {
+		t.Fatalf("Failed with unexpected response: %v %v", uris, err)
+	}
+
+	result, err := r.getSymbol(uris[0])
 
 	if err != nil {
 		t.Fatalf("Failed with unexpected error: %s", err)
 	}
 
+	if result != "GNOG" {
+		t.Fatalf("Failed with unexpected response: %v", result)
+	}
+}
+
+func TestGetSymbol_UnknownInstrumentURI_ReturnsErrSymbolNotFound(t *testing.T) {
+	r, done := robinhoodFixtures(t)
+	defer done()
+	instrumentURI := r.BaseURL + "/instruments/unknown-instrumentID/"
+	result, err := r.getSymbol(instrumentURI)
+
+	if !errors.Is(err, ErrSymbolNotFound) {
+		t.Fatalf("Failed with unexpected error: %v", err)
+	}
+
 	if result != "" {
 		t.Fatalf("Failed with unexpected response: %v", result)
 	}
 }
+
+func TestGetTopMovers_Losers_ReturnsSymbols(t *testing.T) {
+	r, done := robinhoodFixtures(t)
+	defer done()
+	result, err := r.GetTopMovers(Losers)
+
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if len(result) != 4 || result[0] != "CCL" {
+		t.Fatalf("Failed with unexpected response: %v", result)
+	}
+}
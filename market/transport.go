@@ -0,0 +1,223 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Policy - Rate limit and retry settings of an upstream host
+type Policy struct {
+	Rate       float64       // requests per second, 0 disables rate limiting
+	Burst      int           // requests allowed at once, defaults to 1
+	MaxRetries int           // retries after a 429 or 5xx response
+	BaseDelay  time.Duration // backoff before the first retry, doubled for each retry after
+	MaxDelay   time.Duration // cap on the backoff, a longer Retry-After isn't waited for
+}
+
+// Policies - Policy of each upstream host, hosts without one are retried but not rate limited
+var Policies = map[string]Policy{
+	"api.robinhood.com":         {Rate: 5, Burst: 5, MaxRetries: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"financialmodelingprep.com": {Rate: 2, Burst: 2, MaxRetries: 2, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"query1.finance.yahoo.com":  {Rate: 4, Burst: 8, MaxRetries: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"query2.finance.yahoo.com":  {Rate: 4, Burst: 8, MaxRetries: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"autoc.finance.yahoo.com":   {Rate: 4, Burst: 8, MaxRetries: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second},
+	"cutt.ly":                   {Rate: 0.5, Burst: 10, MaxRetries: 1, BaseDelay: time.Second, MaxDelay: 5 * time.Second}, // a scan's alerts at once
+}
+
+// defaultPolicy - Policy of hosts missing from Policies
+var defaultPolicy = Policy{MaxRetries: 2, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+type transport struct {
+	next     http.RoundTripper
+	policies map[string]Policy
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+	jitter   func() float64 // in [0, 1)
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewTransport - Returns an http.RoundTripper that rate limits requests per host with a token bucket and retries
+// 429 and 5xx responses with exponential backoff and jitter, honoring Retry-After. Only GET and HEAD requests are
+// retried.
+func NewTransport(next http.RoundTripper, policies map[string]Policy) http.RoundTripper {
+	return &transport{
+		next:     next,
+		policies: policies,
+		now:      time.Now,
+		sleep:    sleep,
+		jitter:   rand.Float64,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// RoundTrip - Implementation of the http.RoundTripper interface
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	policy, ok := t.policies[host]
+	if !ok {
+		policy = defaultPolicy
+	}
+	retries := policy.MaxRetries
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req, host, policy); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !retryable(resp.StatusCode) || attempt >= retries {
+			return resp, err
+		}
+
+		delay, ok := t.delay(policy, attempt, resp)
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		log.Printf("%s: %s, retrying in %s (%d/%d)", host, resp.Status, delay, attempt+1, retries)
+		if err = t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait - Takes a token from the host's bucket and waits until it's available. Fails fast when the wait would outlast
+// the request's deadline, e.g. the client timeout, giving the token back so later requests don't wait for it.
+func (t *transport) wait(req *http.Request, host string, policy Policy) error {
+	d := t.reserve(host, policy)
+	if deadline, ok := req.Context().Deadline(); ok && d > 0 && t.now().Add(d).After(deadline) {
+		t.release(host)
+		// our own pacing, not the upstream's quota, so it isn't ErrRateLimited
+		return fmt.Errorf("%s: rate limit wait of %s exceeds the request deadline: %w", host, d, context.DeadlineExceeded)
+	}
+
+	if err := t.sleep(req.Context(), d); err != nil {
+		t.release(host)
+		return err
+	}
+
+	return nil
+}
+
+// retryable - Determines if the response status is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// delay - Returns how long to wait before retrying the response: its Retry-After, otherwise the policy's
+// exponential backoff with jitter. False when Retry-After is longer than the policy's MaxDelay.
+func (t *transport) delay(policy Policy, attempt int, resp *http.Response) (time.Duration, bool) {
+	if after, ok := retryAfter(resp.Header.Get("Retry-After"), t.now()); ok {
+		return after, policy.MaxDelay <= 0 || after <= policy.MaxDelay
+	}
+
+	backoff := time.Duration(float64(policy.BaseDelay) * math.Pow(2, float64(attempt)))
+	if policy.MaxDelay > 0 && backoff > policy.MaxDelay {
+		backoff = policy.MaxDelay
+	}
+
+	// equal jitter, half the backoff plus a random half, so concurrent retries spread out
+	return backoff/2 + time.Duration(t.jitter()*float64(backoff/2)), true
+}
+
+// retryAfter - Parses a Retry-After header, either seconds or an HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// reserve - Takes a token from the host's bucket and returns how long to wait before it's available
+func (t *transport) reserve(host string, policy Policy) time.Duration {
+	if policy.Rate <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.buckets[host]
+	if !ok {
+		burst := policy.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		b = &bucket{rate: policy.Rate, burst: float64(burst), tokens: float64(burst), last: t.now()}
+		t.buckets[host] = b
+	}
+
+	return b.reserve(t.now())
+}
+
+// release - Gives back a token reserved from the host's bucket for a request that was never sent
+func (t *transport) release(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if b, ok := t.buckets[host]; ok {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+// bucket - Token bucket refilled at rate tokens per second up to burst
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve - Takes a token, going into debt when the bucket is empty, and returns how long until the debt is repaid
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// sleep - Waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting %s: %w", d, ctx.Err())
+	}
+}
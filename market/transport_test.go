@@ -0,0 +1,182 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scripted - RoundTripper replying with the statuses in order
+type scripted struct {
+	statuses []int
+	headers  []http.Header
+	calls    int
+}
+
+func (s *scripted) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := s.calls
+	s.calls++
+	header := http.Header{}
+	if i < len(s.headers) && s.headers[i] != nil {
+		header = s.headers[i]
+	}
+
+	return &http.Response{
+		Status:     http.StatusText(s.statuses[i]),
+		StatusCode: s.statuses[i],
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func newTestTransport(next http.RoundTripper, policies map[string]Policy, start time.Time) (*transport, *[]time.Duration) {
+	var slept []time.Duration
+	t := NewTransport(next, policies).(*transport)
+	t.now = func() time.Time { return start }
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		if d > 0 {
+			slept = append(slept, d)
+		}
+		return nil
+	}
+	t.jitter = func() float64 { return 0.5 }
+
+	return t, &slept
+}
+
+func TestTransport_ServerErrors_RetriesWithBackoff(t *testing.T) {
+	next := &scripted{statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}}
+	policies := map[string]Policy{"example.com": {MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}}
+	tr, slept := newTestTransport(next, policies, time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/quote", nil)
+	resp, err := tr.RoundTrip(req)
+
+	if err != nil || resp.StatusCode != http.StatusOK || next.calls != 3 {
+		t.Fatalf("Failed with unexpected response: %v %v after %d calls", resp, err, next.calls)
+	}
+	// half the backoff plus half the jitter of the other half
+	if len(*slept) != 2 || (*slept)[0] != 75*time.Millisecond || (*slept)[1] != 150*time.Millisecond {
+		t.Fatalf("Failed with unexpected backoff: %v", *slept)
+	}
+}
+
+func TestTransport_RetriesExhausted_ReturnsLastResponse(t *testing.T) {
+	next := &scripted{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError}}
+	policies := map[string]Policy{"example.com": {MaxRetries: 1, BaseDelay: 100 * time.Millisecond}}
+	tr, _ := newTestTransport(next, policies, time.Now())
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/quote", nil)
+	resp, err := tr.RoundTrip(req)
+
+	if err != nil || resp.StatusCode != http.StatusInternalServerError || next.calls != 2 {
+		t.Fatalf("Failed with unexpected response: %v %v after %d calls", resp, err, next.calls)
+	}
+}
+
+func TestTransport_RetryAfter_WaitsOrGivesUp(t *testing.T) {
+	policies := map[string]Policy{"example.com": {MaxRetries: 2, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}}
+
+	next := &scripted{
+		statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+		headers:  []http.Header{{"Retry-After": []string{"2"}}},
+	}
+	tr, slept := newTestTransport(next, policies, time.Now())
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/quote", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || len(*slept) != 1 || (*slept)[0] != 2*time.Second {
+		t.Fatalf("Failed with unexpected retry: %v %v %v", resp, err, *slept)
+	}
+
+	next = &scripted{
+		statuses: []int{http.StatusTooManyRequests},
+		headers:  []http.Header{{"Retry-After": []string{"3600"}}},
+	}
+	tr, _ = newTestTransport(next, policies, time.Now())
+	resp, err = tr.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests || next.calls != 1 {
+		t.Fatalf("Failed with unexpected retry: %v %v after %d calls", resp, err, next.calls)
+	}
+}
+
+func TestTransport_NotFound_DoesNotRetry(t *testing.T) {
+	next := &scripted{statuses: []int{http.StatusNotFound}}
+	tr, _ := newTestTransport(next, map[string]Policy{}, time.Now())
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/quote", nil)
+	resp, err := tr.RoundTrip(req)
+
+	if err != nil || resp.StatusCode != http.StatusNotFound || next.calls != 1 {
+		t.Fatalf("Failed with unexpected response: %v %v after %d calls", resp, err, next.calls)
+	}
+}
+
+func TestTransport_Burst_RateLimitsPerHost(t *testing.T) {
+	next := &scripted{statuses: []int{200, 200, 200, 200}}
+	policies := map[string]Policy{"example.com": {Rate: 2, Burst: 2}}
+	tr, slept := newTestTransport(next, policies, time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC))
+
+	for _, uri := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3", "https://other.com/1"} {
+		req, _ := http.NewRequest(http.MethodGet, uri, nil)
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+	}
+
+	// the third request to example.com waits for a token, other.com isn't limited
+	if len(*slept) != 1 || (*slept)[0] != 500*time.Millisecond {
+		t.Fatalf("Failed with unexpected waits: %v", *slept)
+	}
+}
+
+func TestTransport_WaitPastDeadline_FailsFastAndReturnsToken(t *testing.T) {
+	next := &scripted{statuses: []int{200, 200}}
+	policies := map[string]Policy{"example.com": {Rate: 0.1, Burst: 1}}
+	start := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	tr, slept := newTestTransport(next, policies, start)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/1", nil)
+	if _, err := tr.RoundTrip(req); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	// the next token is 10s away but the request times out in 5s
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(5*time.Second))
+	defer cancel()
+	resp, err := tr.RoundTrip(req.WithContext(ctx))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRateLimited) || resp != nil || len(*slept) != 0 {
+		t.Fatalf("Failed with unexpected response: %v %v after waiting %v", resp, err, *slept)
+	}
+
+	// the token was given back, so the next request only waits for the first one's debt
+	if _, err = tr.RoundTrip(req); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 10*time.Second || next.calls != 2 {
+		t.Fatalf("Failed with unexpected waits: %v after %d calls", *slept, next.calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC)
+	tcs := map[string]time.Duration{
+		"5":                             5 * time.Second,
+		"Mon, 04 Jan 2021 15:00:30 GMT": 30 * time.Second,
+		"Mon, 04 Jan 2021 14:00:00 GMT": 0,
+	}
+
+	for value, expected := range tcs {
+		actual, ok := retryAfter(value, now)
+		if !ok || actual != expected {
+			t.Fatalf("Failed with unexpected delay for %q: %s", value, actual)
+		}
+	}
+	if _, ok := retryAfter("soon", now); ok {
+		t.Fatalf("Failed with unexpected delay for an invalid header")
+	}
+}
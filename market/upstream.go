@@ -0,0 +1,58 @@
+package market
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Base URLs of the upstream integrations
+const (
+	RobinhoodURL             = "https://api.robinhood.com"
+	FinancialModelingPrepURL = "https://financialmodelingprep.com"
+	YahooQuoteURL            = "https://query2.finance.yahoo.com"
+	YahooChartURL            = "https://query1.finance.yahoo.com"
+	YahooAutocompleteURL     = "https://autoc.finance.yahoo.com"
+)
+
+// Upstream - HTTP client and base URL of an upstream integration, tests point them at httptest servers. Key pools
+// carry their own clock for quota windows, see KeyPool.Now.
+type Upstream struct {
+	Client  *http.Client // defaults to http.DefaultClient, NewUpstream uses the shared client
+	BaseURL string       // scheme and host, e.g. https://api.robinhood.com
+}
+
+// NewUpstream - Returns the Upstream for the base URL with the shared client, which applies the host's Policy and
+// breaker
+func NewUpstream(baseURL string) Upstream {
+	return Upstream{
+		Client:  defaultClient,
+		BaseURL: baseURL,
+	}
+}
+
+// Get - Requests the path, relative to BaseURL unless it's an absolute URL, and returns the response with its
+// body read and closed
+func (u Upstream) Get(path string) (*http.Response, []byte, error) {
+	uri := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		uri = strings.TrimSuffix(u.BaseURL, "/") + path
+	}
+
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
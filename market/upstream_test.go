@@ -0,0 +1,61 @@
+package market
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixture - Recorded upstream response in testdata
+type fixture struct {
+	File   string
+	Status int // defaults to 200
+}
+
+// fixtureServer - Serves the fixtures by request URI, 404 otherwise. Links to the recorded production host are
+// rewritten to the server so follow-up requests stay offline.
+func fixtureServer(t *testing.T, host string, fixtures map[string]fixture) (*httptest.Server, Upstream) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := fixtures[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("Failed with unexpected request: %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadFile(filepath.Join("testdata", f.File))
+		if err != nil {
+			t.Errorf("Failed with unexpected error: %s", err)
+		}
+		if f.Status != 0 {
+			w.WriteHeader(f.Status)
+		}
+		w.Write([]byte(strings.Replace(string(body), host, server.URL, -1)))
+	}))
+
+	return server, Upstream{
+		Client:  server.Client(),
+		BaseURL: server.URL,
+	}
+}
+
+func TestUpstreamGet_AbsoluteURL_IgnoresBaseURL(t *testing.T) {
+	server, u := fixtureServer(t, RobinhoodURL, map[string]fixture{
+		"/instruments/unknown-instrumentID/": {File: "robinhood_instrument_not_found.json", Status: http.StatusNotFound},
+	})
+	defer server.Close()
+	u.BaseURL = "http://127.0.0.1:0"
+
+	resp, body, err := u.Get(server.URL + "/instruments/unknown-instrumentID/")
+
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "Not found") {
+		t.Fatalf("Failed with unexpected response: %d %s", resp.StatusCode, body)
+	}
+}
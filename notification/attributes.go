@@ -0,0 +1,131 @@
+package notification
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+const (
+	// AttributeSymbol - Alerted symbol(s)
+	AttributeSymbol = "symbol"
+	// AttributeAlertType - Alert type, gain or dip
+	AttributeAlertType = "alertType"
+	// AttributeGainBucket - Absolute gain bucket(s), see gainBuckets
+	AttributeGainBucket = "gainBucket"
+	// AttributeGain - Gain percentage, only set on single stock messages
+	AttributeGain = "gain"
+	// AttributeRatingMix - Analyst rating mix(es): bullish, bearish, mixed or unrated
+	AttributeRatingMix = "ratingMix"
+	// AttributeUpdate - "true" when every stock is a follow-up to an earlier alert
+	AttributeUpdate = "update"
+)
+
+// gainBuckets - Upper bounds of the absolute gain buckets, larger gains fall in the "100+" bucket
+var gainBuckets = []struct {
+	max  float64
+	name string
+}{
+	{25, "0-25"},
+	{50, "25-50"},
+	{100, "50-100"},
+}
+
+// messageAttributes - Returns the SNS message attributes for the stocks in a message. Single stock messages have
+// String attributes, batches have String.Array attributes which filter policies match on any element.
+func messageAttributes(stocks []Stock) map[string]*sns.MessageAttributeValue {
+	attributes := map[string]*sns.MessageAttributeValue{
+		AttributeAlertType: stringAttribute(alertType(stocks[0].AlertType)),
+		AttributeUpdate:    stringAttribute(strconv.FormatBool(allUpdates(stocks))),
+	}
+
+	if len(stocks) == 1 {
+		s := stocks[0]
+		attributes[AttributeSymbol] = stringAttribute(s.Symbol)
+		attributes[AttributeGainBucket] = stringAttribute(gainBucket(s.Gain))
+		attributes[AttributeRatingMix] = stringAttribute(ratingMix(s))
+		attributes[AttributeGain] = &sns.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.FormatFloat(s.Gain, 'f', 2, 64)),
+		}
+		return attributes
+	}
+
+	var symbols, buckets, mixes []string
+	for _, s := range stocks {
+		symbols = append(symbols, s.Symbol)
+		buckets = appendUnique(buckets, gainBucket(s.Gain))
+		mixes = appendUnique(mixes, ratingMix(s))
+	}
+	attributes[AttributeSymbol] = arrayAttribute(symbols)
+	attributes[AttributeGainBucket] = arrayAttribute(buckets)
+	attributes[AttributeRatingMix] = arrayAttribute(mixes)
+
+	return attributes
+}
+
+// alertType - Returns the alert type, defaulting to gain like the message header
+func alertType(t string) string {
+	if t == "" {
+		return AlertTypeGain
+	}
+
+	return t
+}
+
+// gainBucket - Returns the bucket of the absolute gain, e.g. "25-50" for a 30% gain or drop
+func gainBucket(gain float64) string {
+	abs := math.Abs(gain)
+	for _, b := range gainBuckets {
+		if abs < b.max {
+			return b.name
+		}
+	}
+
+	return "100+"
+}
+
+// ratingMix - Classifies the analyst ratings as bullish (buys outnumber holds and sells), bearish (sells
+// outnumber buys), mixed or unrated
+func ratingMix(s Stock) string {
+	buys := s.StrongBuy + s.Buy
+	sells := s.Sell + s.StrongSell
+	switch {
+	case buys+s.Hold+sells == 0:
+		return "unrated"
+	case buys > s.Hold+sells:
+		return "bullish"
+	case sells > buys:
+		return "bearish"
+	}
+
+	return "mixed"
+}
+
+func stringAttribute(v string) *sns.MessageAttributeValue {
+	return &sns.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(v),
+	}
+}
+
+func arrayAttribute(v []string) *sns.MessageAttributeValue {
+	b, _ := json.Marshal(v) // marshaling strings can't fail
+	return &sns.MessageAttributeValue{
+		DataType:    aws.String("String.Array"),
+		StringValue: aws.String(string(b)),
+	}
+}
+
+func appendUnique(values []string, v string) []string {
+	for _, e := range values {
+		if e == v {
+			return values
+		}
+	}
+
+	return append(values, v)
+}
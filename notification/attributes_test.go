@@ -0,0 +1,92 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestGainBucket(t *testing.T) {
+	tcs := map[float64]string{
+		10:   "0-25",
+		25:   "25-50",
+		-30:  "25-50",
+		99.9: "50-100",
+		100:  "100+",
+		-250: "100+",
+	}
+
+	for gain, expected := range tcs {
+		if actual := gainBucket(gain); actual != expected {
+			t.Fatalf("Failed for %.2f expected:%s actual:%s", gain, expected, actual)
+		}
+	}
+}
+
+func TestRatingMix(t *testing.T) {
+	tcs := []struct {
+		stock    Stock
+		expected string
+	}{
+		{Stock{}, "unrated"},
+		{Stock{StrongBuy: 2, Buy: 2, Hold: 1, Sell: 1}, "bullish"},
+		{Stock{Buy: 1, Sell: 1, StrongSell: 1}, "bearish"},
+		{Stock{Buy: 2, Hold: 2}, "mixed"},
+	}
+
+	for _, tc := range tcs {
+		if actual := ratingMix(tc.stock); actual != tc.expected {
+			t.Fatalf("Failed for %v expected:%s actual:%s", tc.stock, tc.expected, actual)
+		}
+	}
+}
+
+func TestPublishInputs_PerStock_SetsStockAttributes(t *testing.T) {
+	n := New("arn:aws:sns:us-west-2:123456789012:stonks")
+	n.PerStock = true
+	stocks := []Stock{
+		{Symbol: "GNOG", AlertType: AlertTypeGain, Gain: 55, StrongBuy: 3},
+		{Symbol: "DUD", AlertType: AlertTypeGain, Gain: 20, Sell: 2},
+	}
+
+	inputs, err := n.publishInputs(stocks)
+
+	if err != nil || len(inputs) != 2 {
+		t.Fatalf("Failed with unexpected response: %v %v", inputs, err)
+	}
+	a := inputs[1].MessageAttributes
+	if aws.StringValue(a[AttributeSymbol].StringValue) != "DUD" ||
+		aws.StringValue(a[AttributeGainBucket].StringValue) != "0-25" ||
+		aws.StringValue(a[AttributeRatingMix].StringValue) != "bearish" ||
+		aws.StringValue(a[AttributeAlertType].StringValue) != AlertTypeGain ||
+		aws.StringValue(a[AttributeGain].DataType) != "Number" ||
+		aws.StringValue(a[AttributeGain].StringValue) != "20.00" {
+		t.Fatalf("Failed with unexpected attributes: %v", a)
+	}
+}
+
+func TestPublishInputs_Batch_SetsArrayAttributes(t *testing.T) {
+	n := New("arn:aws:sns:us-west-2:123456789012:stonks")
+	stocks := []Stock{
+		{Symbol: "GNOG", AlertType: AlertTypeDip, Gain: -55, Update: true},
+		{Symbol: "DUD", AlertType: AlertTypeDip, Gain: -60, Update: true},
+	}
+
+	inputs, err := n.publishInputs(stocks)
+
+	if err != nil || len(inputs) != 1 {
+		t.Fatalf("Failed with unexpected response: %v %v", inputs, err)
+	}
+	a := inputs[0].MessageAttributes
+	if aws.StringValue(a[AttributeSymbol].DataType) != "String.Array" ||
+		aws.StringValue(a[AttributeSymbol].StringValue) != `["GNOG","DUD"]` ||
+		aws.StringValue(a[AttributeGainBucket].StringValue) != `["50-100"]` ||
+		aws.StringValue(a[AttributeRatingMix].StringValue) != `["unrated"]` ||
+		aws.StringValue(a[AttributeAlertType].StringValue) != AlertTypeDip ||
+		aws.StringValue(a[AttributeUpdate].StringValue) != "true" {
+		t.Fatalf("Failed with unexpected attributes: %v", a)
+	}
+	if _, ok := a[AttributeGain]; ok {
+		t.Fatal("Failed to omit the gain attribute from a batch")
+	}
+}
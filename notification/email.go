@@ -0,0 +1,252 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+const (
+	// TLSStartTLS - Upgrades the connection with STARTTLS, usually on port 587
+	TLSStartTLS = "starttls"
+	// TLSImplicit - Connects over TLS, usually on port 465
+	TLSImplicit = "tls"
+	// TLSNone - Plaintext connection, only for local relays
+	TLSNone = "none"
+)
+
+type email struct {
+	Addr     string // host:port
+	From     string
+	To       []string
+	Username string
+	Password string
+	TLS      string
+	HTML     *Template
+	Text     *Template
+
+	linker *linker
+
+	tlsConfig *tls.Config
+}
+
+// newEmail - Returns the email notifier for the channel, the password defaults to SMTP_PASSWORD
+func newEmail(c Channel) (*email, error) {
+	if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+		return nil, fmt.Errorf("%s channel requires a smtpHost, from and to", c)
+	}
+
+	e := &email{
+		From:     c.From,
+		To:       c.To,
+		Username: c.Username,
+		Password: c.Password,
+		TLS:      c.TLS,
+		tlsConfig: &tls.Config{
+			ServerName: c.SMTPHost,
+		},
+	}
+	if e.Password == "" {
+		e.Password = os.Getenv("SMTP_PASSWORD")
+	}
+	if e.TLS == "" {
+		e.TLS = TLSStartTLS
+	}
+	if e.TLS != TLSStartTLS && e.TLS != TLSImplicit && e.TLS != TLSNone {
+		return nil, fmt.Errorf("%s channel tls must be %q, %q or %q", c, TLSStartTLS, TLSImplicit, TLSNone)
+	}
+
+	port := c.SMTPPort
+	if port == 0 {
+		port = 587
+		if e.TLS == TLSImplicit {
+			port = 465
+		}
+	}
+	e.Addr = net.JoinHostPort(c.SMTPHost, fmt.Sprint(port))
+
+	var err error
+	if e.linker, err = newLinker(c.Links, c.ShortenLinks); err != nil {
+		return nil, err
+	}
+	if e.HTML, err = c.template(); err != nil {
+		return nil, err
+	}
+	if e.Text, err = LoadTemplate(TemplateText, ""); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Send - Emails the collection of stocks as an HTML digest with a plain text alternative
+func (e *email) Send(stocks []Stock) error {
+	if len(stocks) == 0 {
+		return nil
+	}
+
+	msg, err := e.message(e.linker.apply(stocks))
+	if err != nil {
+		return err
+	}
+
+	return e.deliver(msg)
+}
+
+// SendText - Emails the text as a plain text message
+func (e *email) SendText(subject string, text string) error {
+	var msg bytes.Buffer
+	e.writeHeaders(&msg, subject, "text/plain; charset=UTF-8")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qw := quotedprintable.NewWriter(&msg)
+	if _, err := qw.Write([]byte(text)); err != nil {
+		return err
+	}
+	if err := qw.Close(); err != nil {
+		return err
+	}
+
+	return e.deliver(msg.Bytes())
+}
+
+// deliver - Sends the message to every recipient
+func (e *email) deliver(msg []byte) error {
+	client, err := e.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if e.TLS == TLSStartTLS {
+		if err = client.StartTLS(e.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		host, _, _ := net.SplitHostPort(e.Addr)
+		if err = client.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// dial - Connects to the SMTP server, over TLS when TLS is implicit
+func (e *email) dial() (*smtp.Client, error) {
+	host, _, _ := net.SplitHostPort(e.Addr)
+	if e.TLS == TLSImplicit {
+		conn, err := tls.Dial("tcp", e.Addr, e.tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(conn, host)
+	}
+
+	return smtp.Dial(e.Addr)
+}
+
+// message - Builds the multipart/alternative message with the plain text and HTML parts
+func (e *email) message(stocks []Stock) ([]byte, error) {
+	text, err := e.Text.Render(stocks)
+	if err != nil {
+		return nil, err
+	}
+	html, err := e.HTML.Render(stocks)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	e.writeHeaders(&msg, subject(stocks), fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()))
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+// writeHeaders - Writes the message headers
+func (e *email) writeHeaders(msg *bytes.Buffer, subject string, contentType string) {
+	headers := [][2]string{
+		{"From", e.From},
+		{"To", strings.Join(e.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("UTF-8", subject)},
+		{"Date", now().Format("Mon, 02 Jan 2006 15:04:05 -0700")},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", contentType},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(msg, "%s: %s\r\n", h[0], h[1])
+	}
+}
+
+// subject - Returns the email subject, e.g. "🚀🚀🚀 GNOG, MOON"
+func subject(stocks []Stock) string {
+	var symbols []string
+	for _, s := range stocks {
+		symbols = append(symbols, s.Symbol)
+	}
+
+	update := ""
+	if allUpdates(stocks) {
+		update = " Update"
+	}
+
+	return fmt.Sprintf("%s%s %s", header(stocks[0].AlertType), update, strings.Join(symbols, ", "))
+}
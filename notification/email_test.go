@@ -0,0 +1,164 @@
+package notification
+
+import (
+	"bufio"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// smtpStandIn - Minimal local SMTP server that records the envelope and message of each delivery
+type smtpStandIn struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s := &smtpStandIn{listener: l, data: make(chan string, 1)}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var sb strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				sb.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.data <- sb.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestEmail_Send_DeliversHTMLDigestWithTextAlternative(t *testing.T) {
+	server := newSMTPStandIn(t)
+	defer server.listener.Close()
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	smtpPort, _ := strconv.Atoi(port)
+
+	n, err := NewNotifier(Channel{
+		Type:     ChannelEmail,
+		SMTPHost: host,
+		SMTPPort: smtpPort,
+		TLS:      TLSNone,
+		From:     "stonks@example.com",
+		To:       []string{"a@example.com", "b@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if err = n.Send(templateStocks); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if server.from != "stonks@example.com" || strings.Join(server.to, ",") != "a@example.com,b@example.com" {
+		t.Fatalf("Failed with unexpected envelope: %s %v", server.from, server.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-server.data))
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "🚀🚀🚀 GNOG" {
+		t.Fatalf("Failed with unexpected subject: %s", subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Failed with unexpected content type: %s %v", mediaType, err)
+	}
+	parts := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(p) // quoted-printable is decoded by the reader
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[contentType] = string(b)
+	}
+
+	if !strings.Contains(parts["text/plain"], "Symbol: GNOG") {
+		t.Fatalf("Failed with unexpected text part: %s", parts["text/plain"])
+	}
+	html := parts["text/html"]
+	if !strings.Contains(html, `<a href="https://robinhood.com/stocks/GNOG">robinhood</a>`) ||
+		!strings.Contains(html, "<td>&#43;55.50%</td>") ||
+		!strings.Contains(html, `<a href="https://cutt.ly/gnog">news</a>`) {
+		t.Fatalf("Failed with unexpected html part: %s", html)
+	}
+}
+
+func TestNewNotifier_InvalidEmail_ReturnsError(t *testing.T) {
+	invalid := []Channel{
+		{Type: ChannelEmail, From: "stonks@example.com", To: []string{"a@example.com"}},
+		{Type: ChannelEmail, SMTPHost: "smtp.example.com", To: []string{"a@example.com"}},
+		{Type: ChannelEmail, SMTPHost: "smtp.example.com", From: "stonks@example.com"},
+		{Type: ChannelEmail, SMTPHost: "smtp.example.com", From: "stonks@example.com", To: []string{"a@example.com"}, TLS: "ssl"},
+	}
+
+	for _, c := range invalid {
+		if _, err := NewNotifier(c); err == nil {
+			t.Fatalf("Failed to reject invalid channel: %+v", c)
+		}
+	}
+
+	n, err := NewNotifier(Channel{Type: ChannelEmail, SMTPHost: "smtp.example.com", TLS: TLSImplicit, From: "stonks@example.com", To: []string{"a@example.com"}})
+	if err != nil || n.(*email).Addr != "smtp.example.com:465" {
+		t.Fatalf("Failed with unexpected response: %v %v", n, err)
+	}
+}
@@ -0,0 +1,174 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/lancehumiston/stonk-lambda/url"
+)
+
+// LinkRobinhood - Link included when a channel doesn't choose its links and BROKER_LINKS isn't set
+const LinkRobinhood = "robinhood"
+
+// Link - Named deep link, the URL is a template over the Stock, e.g. "https://finviz.com/quote.ashx?t={{.Symbol}}"
+type Link struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// builtinLinks - Link templates available by name
+var builtinLinks = map[string]string{
+	LinkRobinhood: "https://robinhood.com/stocks/{{.Symbol}}",
+	"webull":      "https://www.webull.com/search?keyword={{.Symbol}}",
+	"tradingview": "https://www.tradingview.com/symbols/{{.Symbol}}/",
+	"yahoo":       "https://finance.yahoo.com/quote/{{.Symbol}}",
+	"finviz":      "https://finviz.com/quote.ashx?t={{.Symbol}}",
+	"stocktwits":  "https://stocktwits.com/symbol/{{.Symbol}}",
+}
+
+var linkFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+}
+
+var (
+	// links - Built-in and BROKER_LINKS link templates by name
+	links = make(map[string]*template.Template)
+	// defaultLinks - Links of channels that don't choose their own
+	defaultLinks = []string{LinkRobinhood}
+	// linksErr - Invalid BROKER_LINKS, returned when creating notifiers
+	linksErr error
+)
+
+func init() {
+	for name, u := range builtinLinks {
+		links[name] = template.Must(template.New(name).Funcs(linkFuncs).Parse(u))
+	}
+
+	if env := os.Getenv("BROKER_LINKS"); env != "" {
+		custom, err := ParseLinks(env)
+		if err != nil {
+			linksErr = fmt.Errorf("BROKER_LINKS: %w", err)
+			return
+		}
+
+		defaultLinks = nil
+		for _, l := range custom {
+			links[l.Name] = template.Must(template.New(l.Name).Funcs(linkFuncs).Parse(l.URL))
+			defaultLinks = append(defaultLinks, l.Name)
+		}
+	}
+}
+
+// ParseLinks - Parses a JSON list of links, entries without a url refer to a built-in link,
+// e.g. [{"name":"tradingview"},{"name":"chart","url":"https://example.com/{{lower .Symbol}}"}]
+func ParseLinks(s string) ([]Link, error) {
+	var l []Link
+	if err := json.Unmarshal([]byte(s), &l); err != nil {
+		return nil, err
+	}
+
+	for i := range l {
+		if l[i].Name == "" {
+			return nil, fmt.Errorf("link %d has no name", i)
+		}
+		if l[i].URL == "" {
+			u, ok := builtinLinks[l[i].Name]
+			if !ok {
+				return nil, fmt.Errorf("unknown link %q", l[i].Name)
+			}
+			l[i].URL = u
+		}
+		if _, err := template.New(l[i].Name).Funcs(linkFuncs).Parse(l[i].URL); err != nil {
+			return nil, fmt.Errorf("link %q: %w", l[i].Name, err)
+		}
+	}
+
+	return l, nil
+}
+
+// linker - Renders a channel's links for each stock
+type linker struct {
+	names   []string
+	shorten bool
+}
+
+// newLinker - Returns the linker for the link names, defaulting to BROKER_LINKS or robinhood
+func newLinker(names []string, shorten bool) (*linker, error) {
+	if linksErr != nil {
+		return nil, linksErr
+	}
+	if len(names) == 0 {
+		names = defaultLinks
+	}
+	for _, n := range names {
+		if _, ok := links[n]; !ok {
+			return nil, fmt.Errorf("unknown link %q", n)
+		}
+	}
+
+	return &linker{
+		names:   names,
+		shorten: shorten,
+	}, nil
+}
+
+// apply - Returns a copy of the stocks with their links set
+func (l *linker) apply(stocks []Stock) []Stock {
+	linked := make([]Stock, len(stocks))
+	for i, s := range stocks {
+		s.Links = nil
+		for _, name := range l.names {
+			var buf bytes.Buffer
+			if err := links[name].Execute(&buf, s); err != nil {
+				log.Printf("link %s: %s", name, err) // log and continue with the other links
+				continue
+			}
+
+			u := buf.String()
+			if l.shorten {
+				u = shorten(u)
+			}
+			s.Links = append(s.Links, Link{
+				Name: name,
+				URL:  u,
+			})
+		}
+		linked[i] = s
+	}
+
+	return linked
+}
+
+var (
+	shortenedMu sync.Mutex
+	shortened   = make(map[string]string)
+)
+
+// shorten - Returns the shortened alias of the URL, or the URL when shortening fails. Aliases are cached so a stock
+// sent to several channels is only shortened once.
+func shorten(u string) string {
+	shortenedMu.Lock()
+	defer shortenedMu.Unlock()
+
+	if alias, ok := shortened[u]; ok {
+		return alias
+	}
+
+	alias, err := getShortenedAlias(u)
+	if err != nil || alias == "" {
+		log.Printf("shorten %s: %v", u, err) // log and send the full link
+		return u
+	}
+	shortened[u] = alias
+
+	return alias
+}
+
+// getShortenedAlias - URL shortener, replaced in tests
+var getShortenedAlias = url.GetShortenedAlias
@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	l, err := ParseLinks(`[{"name":"tradingview"},{"name":"chart","url":"https://example.com/{{lower .Symbol}}"}]`)
+	if err != nil || len(l) != 2 || l[0].URL != builtinLinks["tradingview"] {
+		t.Fatalf("Failed with unexpected response: %v %v", l, err)
+	}
+
+	invalid := []string{
+		`not json`,
+		`[{"url":"https://example.com"}]`,
+		`[{"name":"unknown"}]`,
+		`[{"name":"bad","url":"https://example.com/{{.Symbol"}]`,
+	}
+	for _, s := range invalid {
+		if _, err := ParseLinks(s); err == nil {
+			t.Fatalf("Failed to reject invalid links: %s", s)
+		}
+	}
+}
+
+func TestLinker_Apply_RendersAndShortensLinks(t *testing.T) {
+	original := getShortenedAlias
+	getShortenedAlias = func(u string) (string, error) {
+		if u == "https://finviz.com/quote.ashx?t=GNOG" {
+			return "", errors.New("cuttly down")
+		}
+		return "https://cutt.ly/" + u[len(u)-4:], nil
+	}
+	defer func() { getShortenedAlias = original }()
+
+	l, err := newLinker([]string{"yahoo", "finviz"}, true)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	stocks := []Stock{{Symbol: "GNOG"}}
+
+	linked := l.apply(stocks)
+
+	if len(stocks[0].Links) != 0 {
+		t.Fatal("Failed to leave the original stocks unchanged")
+	}
+	expected := []Link{
+		{Name: "yahoo", URL: "https://cutt.ly/GNOG"},
+		{Name: "finviz", URL: "https://finviz.com/quote.ashx?t=GNOG"},
+	}
+	if len(linked[0].Links) != 2 || linked[0].Links[0] != expected[0] || linked[0].Links[1] != expected[1] {
+		t.Fatalf("Failed with unexpected links: %v", linked[0].Links)
+	}
+
+	if _, err = newLinker([]string{"unknown"}, false); err == nil {
+		t.Fatal("Failed to reject unknown link")
+	}
+}
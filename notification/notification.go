@@ -12,22 +12,68 @@ import (
 
 // Stock - Stock overview for messaging
 type Stock struct {
-	Symbol          string  `json:"symbol"`
-	Gain            float64 `json:"gain"`
-	CurrentPrice    float64 `json:"currentPrice"`
-	TargetHighPrice float64 `json:"targetHighPrice"`
-	TargetLowPrice  float64 `json:"targetLowPrice"`
-	TargetMeanPrice float64 `json:"targetMeanPrice"`
-	StrongBuy       int64   `json:"strongBuy"`
-	Buy             int64   `json:"buy"`
-	Hold            int64   `json:"hold"`
-	Sell            int64   `json:"sell"`
-	StrongSell      int64   `json:"strongSell"`
-	NewsURL         string  `json:"newsUrl"`
+	Symbol          string        `json:"symbol"`
+	AlertType       string        `json:"alertType"`
+	Gain            float64       `json:"gain"`
+	CurrentPrice    float64       `json:"currentPrice"`
+	TargetHighPrice float64       `json:"targetHighPrice"`
+	TargetLowPrice  float64       `json:"targetLowPrice"`
+	TargetMeanPrice float64       `json:"targetMeanPrice"`
+	StrongBuy       int64         `json:"strongBuy"`
+	Buy             int64         `json:"buy"`
+	Hold            int64         `json:"hold"`
+	Sell            int64         `json:"sell"`
+	StrongSell      int64         `json:"strongSell"`
+	NewsURL         string        `json:"newsUrl"`
+	Score           float64       `json:"score"`
+	ScoreFactors    []ScoreFactor `json:"scoreFactors"`
+	TrendPeriod     string        `json:"trendPeriod"`   // period the rating changes are measured from, e.g. "-1m"
+	BullishChange   int64         `json:"bullishChange"` // change in strongBuy+buy since TrendPeriod
+	BearishChange   int64         `json:"bearishChange"` // change in sell+strongSell since TrendPeriod
+	Update          bool          `json:"update"`        // follow-up to an earlier alert for the stock today
+	PreviousGain    float64       `json:"previousGain"`  // gain when last alerted, set on updates
+	PreviousPrice   float64       `json:"previousPrice"` // price when last alerted, set on updates
+	AlertPrice      float64       `json:"alertPrice"`    // price of the original alert, set on follow-ups
+	Move            float64       `json:"move"`          // percentage move since AlertPrice, set on follow-ups
+	Links           []Link        `json:"links"`         // broker and charting links, set per channel
+}
+
+// ScoreFactor - Contribution of a single factor to the stock's score
+type ScoreFactor struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+}
+
+const (
+	// AlertTypeGain - Stock alerted from the top gainers
+	AlertTypeGain = "gain"
+	// AlertTypeDip - Stock alerted as a reversal candidate from the top losers
+	AlertTypeDip = "dip"
+	// AlertTypeTargetMean - Follow-up for an alerted stock that reached its mean analyst price target
+	AlertTypeTargetMean = "targetMean"
+	// AlertTypeTargetHigh - Follow-up for an alerted stock that reached its high analyst price target
+	AlertTypeTargetHigh = "targetHigh"
+	// AlertTypeStopLoss - Follow-up for an alerted stock that fell below its stop-loss
+	AlertTypeStopLoss = "stopLoss"
+)
+
+// headers - Message header per alert type
+var headers = map[string]string{
+	AlertTypeGain:       "🚀🚀🚀",
+	AlertTypeDip:        "📉📉📉 Dip",
+	AlertTypeTargetMean: "🎯 Target Mean",
+	AlertTypeTargetHigh: "🎯🎯 Target High",
+	AlertTypeStopLoss:   "🛑 Stop Loss",
 }
 
 type notification struct {
 	SnsTopicArn string
+	Template    *Template
+	MaxLength   int  // characters per publish, longer messages are split into ordered parts
+	PerStock    bool // publish each stock as its own message so filter policies match a single stock
+	Structured  bool // publish with the json MessageStructure, machine protocols receive a Payload
+
+	linker *linker
 }
 
 // New - Public constructor for notification
@@ -38,15 +84,44 @@ func New(snsTopicArn string) *notification {
 
 	return &notification{
 		SnsTopicArn: snsTopicArn,
+		Template:    MustLoadTemplate(TemplateText),
+		MaxLength:   maxLengths[ChannelSNS],
+		linker:      &linker{names: defaultLinks},
 	}
 }
 
-// Send - Sends the collection of stocks as a SMS via AWS's SNS
+// Send - Sends the collection of stocks as a SMS via AWS's SNS, publishing the parts in order when the message
+// exceeds MaxLength. Each publish carries message attributes for subscription filter policies.
 func (n *notification) Send(stocks []Stock) error {
 	if len(stocks) == 0 {
 		return nil
 	}
 
+	inputs, err := n.publishInputs(n.linker.apply(stocks))
+	if err != nil {
+		return err
+	}
+
+	return n.publish(inputs)
+}
+
+// SendText - Sends the text via AWS's SNS, publishing the parts in order when it exceeds MaxLength. The subject is
+// used by email subscribers.
+func (n *notification) SendText(subject string, text string) error {
+	var inputs []*sns.PublishInput
+	for _, p := range splitText(text, n.MaxLength) {
+		inputs = append(inputs, &sns.PublishInput{
+			Message:  aws.String(p),
+			Subject:  aws.String(subject),
+			TopicArn: aws.String(n.SnsTopicArn),
+		})
+	}
+
+	return n.publish(inputs)
+}
+
+// publish - Publishes the inputs in order
+func (n *notification) publish(inputs []*sns.PublishInput) error {
 	sess, err := session.NewSession(&aws.Config{
 		Region: aws.String("us-west-2"),
 	})
@@ -57,49 +132,128 @@ func (n *notification) Send(stocks []Stock) error {
 
 	client := sns.New(sess)
 
-	var sb strings.Builder
-	sb.WriteString("🚀🚀🚀")
+	for _, input := range inputs {
+		result, err := client.Publish(input)
+		if err != nil {
+			return err
+		}
+
+		log.Println(result)
+	}
+
+	return nil
+}
+
+// publishInputs - Renders the stocks into ordered publishes, one batch or one per stock when PerStock is set
+func (n *notification) publishInputs(stocks []Stock) ([]*sns.PublishInput, error) {
+	batches := [][]Stock{stocks}
+	if n.PerStock {
+		batches = nil
+		for _, s := range stocks {
+			batches = append(batches, []Stock{s})
+		}
+	}
+
+	var inputs []*sns.PublishInput
+	for _, b := range batches {
+		parts, err := n.Template.RenderParts(b, n.MaxLength)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, p := range parts {
+			input := &sns.PublishInput{
+				Message:           aws.String(p.Text),
+				MessageAttributes: messageAttributes(p.Stocks),
+				TopicArn:          aws.String(n.SnsTopicArn),
+			}
+			if n.Structured {
+				message, err := structuredMessage(p.Text, newPayload(p.Stocks, i+1, len(parts)))
+				if err != nil {
+					return nil, err
+				}
+				input.Message = aws.String(message)
+				input.MessageStructure = aws.String("json")
+			}
+
+			inputs = append(inputs, input)
+		}
+	}
+
+	return inputs, nil
+}
+
+// formatScoreFactors - Formats the score breakdown, e.g. "gain 12.5, rating 18.8"
+func formatScoreFactors(factors []ScoreFactor) string {
+	var parts []string
+	for _, f := range factors {
+		parts = append(parts, fmt.Sprintf("%s %.1f", f.Name, f.Points))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// GroupByAlertType - Splits the stocks into one batch per alert type, in order of first appearance, since a message
+// has a single header
+func GroupByAlertType(stocks []Stock) [][]Stock {
+	var groups [][]Stock
+	index := make(map[string]int)
 	for _, s := range stocks {
-		sb.WriteString(fmt.Sprintf(`
-Symbol: %s
-Gainz: %.2f%%
-CurrentPrice: %.2f
-TargetHigh: %.2f
-TargetLow: %.2f
-TargetMean: %.2f
-StrongBuy: %d
-Buy: %d
-Hold: %d
-Sell: %d
-StrongSell: %d
-%s
-https://robinhood.com/stocks/%s
-`,
-			s.Symbol,
-			s.Gain,
-			s.CurrentPrice,
-			s.TargetHighPrice,
-			s.TargetLowPrice,
-			s.TargetMeanPrice,
-			s.StrongBuy,
-			s.Buy,
-			s.Hold,
-			s.Sell,
-			s.StrongSell,
-			s.NewsURL,
-			s.Symbol))
-	}
-	input := &sns.PublishInput{
-		Message:  aws.String(sb.String()),
-		TopicArn: aws.String(n.SnsTopicArn),
-	}
-
-	result, err := client.Publish(input)
-	if err != nil {
-		return err
+		i, ok := index[s.AlertType]
+		if !ok {
+			i = len(groups)
+			index[s.AlertType] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], s)
 	}
 
-	log.Println(result)
+	return groups
+}
 
-	return nil
+// header - Returns the message header for the alert type
+func header(alertType string) string {
+	if h, ok := headers[alertType]; ok {
+		return h
+	}
+
+	return headers[AlertTypeGain]
+}
+
+// formatTrend - Formats the analyst rating change, e.g. "buy +2 sell -1 since -1m"
+func formatTrend(s Stock) string {
+	if s.TrendPeriod == "" {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("buy %+d sell %+d since %s", s.BullishChange, s.BearishChange, s.TrendPeriod)
+}
+
+// allUpdates - Determines if every stock is a follow-up to an earlier alert
+func allUpdates(stocks []Stock) bool {
+	for _, s := range stocks {
+		if !s.Update {
+			return false
+		}
+	}
+
+	return len(stocks) > 0
+}
+
+// formatFollowUp - Formats the line with the move since the original alert, empty for new alerts
+func formatFollowUp(s Stock) string {
+	if s.AlertPrice == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\nAlerted: %.2f, moved %+.2f%%", s.AlertPrice, s.Move)
+}
+
+// formatUpdate - Formats the line marking a follow-up alert, empty for new alerts
+func formatUpdate(s Stock) string {
+	if !s.Update {
+		return ""
+	}
+
+	return fmt.Sprintf("\nUpdate: was %.2f%% at %.2f", s.PreviousGain, s.PreviousPrice)
 }
@@ -1 +1,62 @@
 package notification
+
+import "testing"
+
+func TestHeader(t *testing.T) {
+	tcs := map[string]string{
+		AlertTypeGain: "🚀🚀🚀",
+		AlertTypeDip:  "📉📉📉 Dip",
+		"":            "🚀🚀🚀",
+	}
+
+	for alertType, expected := range tcs {
+		if actual := header(alertType); actual != expected {
+			t.Fatalf("Failed expected:%s actual:%s", expected, actual)
+		}
+	}
+}
+
+func TestFormatTrend(t *testing.T) {
+	tcs := []struct {
+		stock    Stock
+		expected string
+	}{
+		{Stock{}, "n/a"},
+		{Stock{TrendPeriod: "-1m", BullishChange: 2, BearishChange: -1}, "buy +2 sell -1 since -1m"},
+	}
+
+	for _, tc := range tcs {
+		if actual := formatTrend(tc.stock); actual != tc.expected {
+			t.Fatalf("Failed expected:%s actual:%s", tc.expected, actual)
+		}
+	}
+}
+
+func TestFormatUpdate(t *testing.T) {
+	if actual := formatUpdate(Stock{}); actual != "" {
+		t.Fatalf("Failed with unexpected response for new alert: %s", actual)
+	}
+
+	expected := "\nUpdate: was 50.00% at 10.00"
+	if actual := formatUpdate(Stock{Update: true, PreviousGain: 50, PreviousPrice: 10}); actual != expected {
+		t.Fatalf("Failed expected:%s actual:%s", expected, actual)
+	}
+
+	if allUpdates([]Stock{{Update: true}, {}}) || !allUpdates([]Stock{{Update: true}}) {
+		t.Fatal("Failed with unexpected allUpdates response")
+	}
+}
+
+func TestGroupByAlertType(t *testing.T) {
+	stocks := []Stock{
+		{Symbol: "DUD", AlertType: AlertTypeStopLoss},
+		{Symbol: "GNOG", AlertType: AlertTypeTargetMean},
+		{Symbol: "MOON", AlertType: AlertTypeStopLoss},
+	}
+
+	groups := GroupByAlertType(stocks)
+
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][1].Symbol != "MOON" || len(groups[1]) != 1 || groups[1][0].Symbol != "GNOG" {
+		t.Fatalf("Failed with unexpected groups: %v", groups)
+	}
+}
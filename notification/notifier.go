@@ -0,0 +1,264 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Notifier - Sends a collection of stocks to a notification channel
+type Notifier interface {
+	Send(stocks []Stock) error
+}
+
+// TextNotifier - Sends free-form text, such as a digest, to a notification channel
+type TextNotifier interface {
+	SendText(subject string, text string) error
+}
+
+const (
+	// ChannelSNS - AWS SNS topic
+	ChannelSNS = "sns"
+	// ChannelSlack - Slack incoming webhook
+	ChannelSlack = "slack"
+	// ChannelDiscord - Discord webhook
+	ChannelDiscord = "discord"
+	// ChannelWebhook - Generic webhook that receives the stocks as JSON
+	ChannelWebhook = "webhook"
+	// ChannelEmail - SMTP email digest
+	ChannelEmail = "email"
+)
+
+// Channel - Notification channel configuration
+type Channel struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"` // used in logs, defaults to Type
+	TopicArn string `json:"topicArn,omitempty"`
+	URL      string `json:"url,omitempty"`
+	// Template - Built-in or env overridden template name, defaults to markdown for slack and discord, html for
+	// email, otherwise text
+	Template     string `json:"template,omitempty"`
+	TemplateFile string `json:"templateFile,omitempty"`
+	// Compact - Uses the one line per stock sms template when Template isn't set
+	Compact bool `json:"compact,omitempty"`
+	// MaxLength - Characters per message before splitting into ordered parts, defaults per type, -1 disables
+	MaxLength int `json:"maxLength,omitempty"`
+	// PerStock - Publishes one sns message per stock so subscription filter policies match individual stocks
+	PerStock bool `json:"perStock,omitempty"`
+	// Structured - Publishes sns messages with per protocol bodies, the text for sms and email and the versioned
+	// Payload JSON for sqs, lambda and http subscribers
+	Structured bool `json:"structured,omitempty"`
+	// Links - Names of the built-in or BROKER_LINKS links to include per stock, defaults to every BROKER_LINKS link
+	// or robinhood
+	Links []string `json:"links,omitempty"`
+	// ShortenLinks - Shortens the links through cutt.ly
+	ShortenLinks bool `json:"shortenLinks,omitempty"`
+	// QuietHours - Holds alerts in the queue during the window and sends them together once it ends
+	QuietHours *QuietHours `json:"quietHours,omitempty"`
+
+	// SMTP settings for email channels, the password defaults to SMTP_PASSWORD
+	SMTPHost string   `json:"smtpHost,omitempty"`
+	SMTPPort int      `json:"smtpPort,omitempty"` // defaults to 587, or 465 for implicit tls
+	TLS      string   `json:"tls,omitempty"`      // starttls (default), tls or none
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+}
+
+func (c Channel) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.Type
+}
+
+// ParseChannels - Parses a JSON list of channels, e.g. [{"type":"slack","url":"https://hooks.slack.com/..."}]
+func ParseChannels(s string) ([]Channel, error) {
+	var channels []Channel
+	if err := json.Unmarshal([]byte(s), &channels); err != nil {
+		return nil, err
+	}
+
+	for _, c := range channels {
+		if _, err := NewNotifier(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return channels, nil
+}
+
+// NewNotifier - Returns the Notifier for the channel
+func NewNotifier(c Channel) (Notifier, error) {
+	if l := c.maxLength(); l > 0 && l <= partPrefixReserve {
+		return nil, fmt.Errorf("%s channel maxLength %d is too small", c, l)
+	}
+	if c.QuietHours != nil {
+		if _, _, _, err := c.QuietHours.window(); err != nil {
+			return nil, fmt.Errorf("%s channel %w", c, err)
+		}
+	}
+
+	switch c.Type {
+	case ChannelSNS:
+		if c.TopicArn == "" {
+			return nil, fmt.Errorf("%s channel requires a topicArn", c)
+		}
+		t, err := c.template()
+		if err != nil {
+			return nil, err
+		}
+		l, err := newLinker(c.Links, c.ShortenLinks)
+		if err != nil {
+			return nil, err
+		}
+		return &notification{
+			SnsTopicArn: c.TopicArn,
+			Template:    t,
+			MaxLength:   c.maxLength(),
+			PerStock:    c.PerStock,
+			Structured:  c.Structured,
+			linker:      l,
+		}, nil
+	case ChannelEmail:
+		return newEmail(c)
+	case ChannelSlack, ChannelDiscord, ChannelWebhook:
+		if c.URL == "" {
+			return nil, fmt.Errorf("%s channel requires a url", c)
+		}
+		t, err := c.template()
+		if err != nil {
+			return nil, err
+		}
+		l, err := newLinker(c.Links, c.ShortenLinks)
+		if err != nil {
+			return nil, err
+		}
+		return &webhook{
+			Type:      c.Type,
+			URL:       c.URL,
+			Template:  t,
+			MaxLength: c.maxLength(),
+			Client:    &http.Client{Timeout: webhookTimeout},
+			linker:    l,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown channel type %q", c.Type)
+}
+
+// template - Loads the channel's message template
+func (c Channel) template() (*Template, error) {
+	name := c.Template
+	if name == "" && c.TemplateFile == "" {
+		name = TemplateText
+		if c.Compact {
+			name = TemplateSMS
+		} else if c.Type == ChannelSlack || c.Type == ChannelDiscord {
+			name = TemplateMarkdown
+		} else if c.Type == ChannelEmail {
+			name = TemplateHTML
+		}
+	}
+
+	return LoadTemplate(name, c.TemplateFile)
+}
+
+// maxLength - Returns the channel's message size limit, 0 when messages aren't split
+func (c Channel) maxLength() int {
+	if c.MaxLength < 0 {
+		return 0
+	}
+	if c.MaxLength > 0 {
+		return c.MaxLength
+	}
+
+	return maxLengths[c.Type]
+}
+
+type fanout struct {
+	channels  []Channel
+	notifiers []Notifier
+}
+
+// NewFanout - Returns a Notifier that sends to every channel, a failure in one channel doesn't block the others.
+// Channels with quiet hours hold their alerts in the queue. The returned notifier is also a TextNotifier.
+func NewFanout(channels []Channel, queue Queue) (*fanout, error) {
+	f := &fanout{}
+	for _, c := range channels {
+		n, err := NewNotifier(c)
+		if err != nil {
+			return nil, err
+		}
+		if c.QuietHours != nil {
+			if n, err = newQuiet(c, n, queue); err != nil {
+				return nil, err
+			}
+		}
+
+		f.channels = append(f.channels, c)
+		f.notifiers = append(f.notifiers, n)
+	}
+
+	return f, nil
+}
+
+// Send - Sends the stocks to every channel concurrently and returns an error naming the channels that failed.
+// Without stocks, only alerts held during quiet hours that have ended are sent.
+func (f *fanout) Send(stocks []Stock) error {
+	if len(stocks) == 0 {
+		return f.each(func(n Notifier) error {
+			if r, ok := n.(releaser); ok {
+				return r.Release()
+			}
+			return nil
+		})
+	}
+
+	return f.each(func(n Notifier) error {
+		return n.Send(stocks)
+	})
+}
+
+// SendText - Sends the text to every channel concurrently and returns an error naming the channels that failed
+func (f *fanout) SendText(subject string, text string) error {
+	return f.each(func(n Notifier) error {
+		t, ok := n.(TextNotifier)
+		if !ok {
+			return fmt.Errorf("text messages aren't supported")
+		}
+		return t.SendText(subject, text)
+	})
+}
+
+// each - Calls send for every channel concurrently, a failure in one channel doesn't block the others
+func (f *fanout) each(send func(n Notifier) error) error {
+	errCh := make(chan error, len(f.notifiers))
+	for i, v := range f.notifiers {
+		go func(channel Channel, notifier Notifier, errCh chan<- error) {
+			if err := send(notifier); err != nil {
+				errCh <- fmt.Errorf("%s: %w", channel, err)
+				return
+			}
+
+			errCh <- nil
+		}(f.channels[i], v, errCh)
+	}
+
+	var failures []string
+	for i := 0; i < cap(errCh); i++ {
+		if err := <-errCh; err != nil {
+			log.Println(err) // log and continue with other channels
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d channels failed: %s", len(failures), len(f.notifiers), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder - Test server that records the bodies posted to it
+type recorder struct {
+	mu     sync.Mutex
+	bodies []string
+	status int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b, _ := ioutil.ReadAll(req.Body)
+	r.mu.Lock()
+	r.bodies = append(r.bodies, string(b))
+	r.mu.Unlock()
+	w.WriteHeader(r.status)
+}
+
+func TestFanout_FailingChannel_SendsToOthers(t *testing.T) {
+	slack := &recorder{status: http.StatusOK}
+	slackServer := httptest.NewServer(slack)
+	defer slackServer.Close()
+	discord := &recorder{status: http.StatusNoContent}
+	discordServer := httptest.NewServer(discord)
+	defer discordServer.Close()
+	broken := &recorder{status: http.StatusInternalServerError}
+	brokenServer := httptest.NewServer(broken)
+	defer brokenServer.Close()
+
+	n, err := NewFanout([]Channel{
+		{Type: ChannelSlack, URL: slackServer.URL},
+		{Type: ChannelWebhook, Name: "broken", URL: brokenServer.URL},
+		{Type: ChannelDiscord, URL: discordServer.URL},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	err = n.Send([]Stock{{Symbol: "GNOG", AlertType: AlertTypeGain, Gain: 55}})
+
+	if err == nil || !strings.HasPrefix(err.Error(), "1 of 3 channels failed: broken:") {
+		t.Fatalf("Failed with unexpected response: %v", err)
+	}
+
+	var s struct {
+		Text string `json:"text"`
+	}
+	if len(slack.bodies) != 1 || json.Unmarshal([]byte(slack.bodies[0]), &s) != nil || !strings.Contains(s.Text, "**GNOG** +55.00%") || !strings.Contains(s.Text, "[robinhood](https://robinhood.com/stocks/GNOG)") {
+		t.Fatalf("Failed with unexpected slack body: %v", slack.bodies)
+	}
+
+	var d struct {
+		Content string `json:"content"`
+	}
+	if len(discord.bodies) != 1 || json.Unmarshal([]byte(discord.bodies[0]), &d) != nil || !strings.Contains(d.Content, "**GNOG**") {
+		t.Fatalf("Failed with unexpected discord body: %v", discord.bodies)
+	}
+}
+
+func TestWebhook_Send_PostsStocks(t *testing.T) {
+	hook := &recorder{status: http.StatusAccepted}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	n, err := NewNotifier(Channel{Type: ChannelWebhook, URL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if err = n.Send([]Stock{{Symbol: "GNOG"}, {Symbol: "MOON"}}); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	var p webhookPayload
+	if err = json.Unmarshal([]byte(hook.bodies[0]), &p); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(p.Stocks) != 2 || p.Stocks[1].Symbol != "MOON" || p.Text == "" || p.SchemaVersion != PayloadSchemaVersion || p.Run.SentAt == "" {
+		t.Fatalf("Failed with unexpected payload: %v", p)
+	}
+}
+
+func TestWebhook_HungEndpoint_TimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	n, err := NewNotifier(Channel{Type: ChannelSlack, URL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	hook := n.(*webhook)
+	if hook.Client == nil || hook.Client.Timeout != webhookTimeout {
+		t.Fatalf("Failed with unexpected client: %v", hook.Client)
+	}
+	hook.Client.Timeout = 50 * time.Millisecond
+
+	if err = n.Send([]Stock{{Symbol: "GNOG"}}); err == nil {
+		t.Fatalf("Failed to time out")
+	}
+}
+
+func TestParseChannels_InvalidChannels_ReturnsError(t *testing.T) {
+	invalid := []string{
+		`not json`,
+		`[{"type":"pager"}]`,
+		`[{"type":"slack"}]`,
+		`[{"type":"sns"}]`,
+	}
+
+	for _, s := range invalid {
+		if _, err := ParseChannels(s); err == nil {
+			t.Fatalf("Failed to reject invalid channels: %s", s)
+		}
+	}
+
+	channels, err := ParseChannels(`[{"type":"sns","topicArn":"arn:aws:sns:us-west-2:123456789012:stonks"},{"type":"discord","url":"https://discord.com/api/webhooks/1/a"}]`)
+	if err != nil || len(channels) != 2 {
+		t.Fatalf("Failed with unexpected response: %v %v", channels, err)
+	}
+}
+
+func TestFanout_SendText_PostsPerType(t *testing.T) {
+	discord := &recorder{status: http.StatusNoContent}
+	discordServer := httptest.NewServer(discord)
+	defer discordServer.Close()
+	hook := &recorder{status: http.StatusOK}
+	hookServer := httptest.NewServer(hook)
+	defer hookServer.Close()
+
+	n, err := NewFanout([]Channel{
+		{Type: ChannelDiscord, URL: discordServer.URL, MaxLength: 20},
+		{Type: ChannelWebhook, URL: hookServer.URL},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if err = n.SendText("Digest", "GNOG alerted\nMOON rejected\n"); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if len(discord.bodies) != 2 || discord.bodies[0] != `{"content":"1/2 GNOG alerted\n"}` {
+		t.Fatalf("Failed with unexpected discord bodies: %v", discord.bodies)
+	}
+	if len(hook.bodies) != 1 || hook.bodies[0] != `{"subject":"Digest","text":"GNOG alerted\nMOON rejected\n"}` {
+		t.Fatalf("Failed with unexpected webhook bodies: %v", hook.bodies)
+	}
+}
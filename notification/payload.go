@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PayloadSchemaVersion - Version of the Payload schema, bumped on breaking changes so consumers can reject
+// payloads they don't understand
+const PayloadSchemaVersion = 1
+
+// Payload - Machine readable alert published to SQS, Lambda and HTTP subscribers
+type Payload struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	Run           Run     `json:"run"`
+	Stocks        []Stock `json:"stocks"`
+}
+
+// Run - Metadata about the publish
+type Run struct {
+	Source    string `json:"source"`
+	SentAt    string `json:"sentAt"` // RFC3339 UTC
+	AlertType string `json:"alertType"`
+	Update    bool   `json:"update"` // every stock is a follow-up to an earlier alert
+	Part      int    `json:"part"`   // 1 based index of the message when the alert was split
+	Parts     int    `json:"parts"`
+}
+
+// now - Current time, replaced in tests
+var now = time.Now
+
+// protocolsText - SNS protocols that receive the rendered human readable message
+var protocolsText = []string{"default", "sms", "email"}
+
+// protocolsJSON - SNS protocols that receive the serialized Payload
+var protocolsJSON = []string{"sqs", "lambda", "http", "https", "email-json", "firehose", "application"}
+
+// newPayload - Returns the payload for a part of a message
+func newPayload(stocks []Stock, part, parts int) Payload {
+	return Payload{
+		SchemaVersion: PayloadSchemaVersion,
+		Run: Run{
+			Source:    "stonk-lambda",
+			SentAt:    now().UTC().Format(time.RFC3339),
+			AlertType: alertType(stocks[0].AlertType),
+			Update:    allUpdates(stocks),
+			Part:      part,
+			Parts:     parts,
+		},
+		Stocks: stocks,
+	}
+}
+
+// structuredMessage - Returns the SNS json MessageStructure body with the text for human protocols and the
+// payload for machine protocols
+func structuredMessage(text string, payload Payload) (string, error) {
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	bodies := make(map[string]string)
+	for _, protocol := range protocolsText {
+		bodies[protocol] = text
+	}
+	for _, protocol := range protocolsJSON {
+		bodies[protocol] = string(p)
+	}
+
+	message, err := json.Marshal(bodies)
+	if err != nil {
+		return "", err
+	}
+
+	return string(message), nil
+}
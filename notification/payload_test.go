@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestPublishInputs_Structured_PerProtocolBodies(t *testing.T) {
+	now = func() time.Time { return time.Date(2021, 1, 4, 15, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	n := New("arn:aws:sns:us-west-2:123456789012:stonks")
+	n.Structured = true
+
+	inputs, err := n.publishInputs(templateStocks)
+
+	if err != nil || len(inputs) != 1 {
+		t.Fatalf("Failed with unexpected response: %v %v", inputs, err)
+	}
+	if aws.StringValue(inputs[0].MessageStructure) != "json" {
+		t.Fatalf("Failed with unexpected MessageStructure: %v", inputs[0].MessageStructure)
+	}
+
+	var bodies map[string]string
+	if err = json.Unmarshal([]byte(aws.StringValue(inputs[0].Message)), &bodies); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	text, _ := MustLoadTemplate(TemplateText).Render(templateStocks)
+	for _, protocol := range []string{"default", "sms", "email"} {
+		if bodies[protocol] != text {
+			t.Fatalf("Failed with unexpected %s body: %s", protocol, bodies[protocol])
+		}
+	}
+
+	for _, protocol := range []string{"sqs", "lambda", "https"} {
+		var p Payload
+		if err = json.Unmarshal([]byte(bodies[protocol]), &p); err != nil {
+			t.Fatalf("Failed with unexpected %s body: %s", protocol, err)
+		}
+		if p.SchemaVersion != PayloadSchemaVersion || p.Run.SentAt != "2021-01-04T15:00:00Z" || p.Run.AlertType != AlertTypeGain ||
+			p.Run.Part != 1 || p.Run.Parts != 1 || len(p.Stocks) != 1 || p.Stocks[0].TargetMeanPrice != 15 {
+			t.Fatalf("Failed with unexpected %s payload: %v", protocol, p)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package notification
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultQuietTimeZone - Time zone of quiet hours without one, the market's
+const defaultQuietTimeZone = "America/New_York"
+
+// QuietHours - Daily window when a channel's alerts are held, then released together once it ends
+type QuietHours struct {
+	Start    string `json:"start"`              // 15:04, e.g. 21:00
+	End      string `json:"end"`                // 15:04, before Start for windows that wrap past midnight
+	TimeZone string `json:"timeZone,omitempty"` // IANA name, defaults to America/New_York
+}
+
+// Queue - Holds alerts for channels in quiet hours, e.g. a data.Queue
+type Queue interface {
+	// Hold - Queues the alert payloads for the channel
+	Hold(channel string, payloads []string) error
+	// Release - Removes and returns the payloads queued for the channel, oldest first
+	Release(channel string) ([]string, error)
+}
+
+// releaser - Notifier that holds alerts and sends them once it's allowed to
+type releaser interface {
+	Release() error
+}
+
+// window - Returns the start and end as minutes into the day and the time zone they're in
+func (q QuietHours) window() (int, int, *time.Location, error) {
+	var minutes [2]int
+	for i, v := range []string{q.Start, q.End} {
+		t, err := time.Parse("15:04", v)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("quiet hours %q: %w", v, err)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+
+	name := q.TimeZone
+	if name == "" {
+		name = defaultQuietTimeZone
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("quiet hours: %w", err)
+	}
+
+	return minutes[0], minutes[1], location, nil
+}
+
+// contains - Determines if t falls within the quiet hours, an empty window when Start equals End
+func contains(start, end int, location *time.Location, t time.Time) bool {
+	t = t.In(location)
+	minute := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+
+	return minute >= start || minute < end
+}
+
+type quiet struct {
+	Channel    string // queue key
+	start, end int
+	location   *time.Location
+	next       Notifier
+	queue      Queue
+}
+
+// newQuiet - Returns the channel's notifier holding its alerts in the queue during quiet hours
+func newQuiet(c Channel, next Notifier, queue Queue) (*quiet, error) {
+	if queue == nil {
+		return nil, fmt.Errorf("%s channel quiet hours require a queue", c)
+	}
+	start, end, location, err := c.QuietHours.window()
+	if err != nil {
+		return nil, fmt.Errorf("%s channel %w", c, err)
+	}
+
+	return &quiet{
+		Channel:  c.queueKey(),
+		start:    start,
+		end:      end,
+		location: location,
+		next:     next,
+		queue:    queue,
+	}, nil
+}
+
+// Send - Holds the stocks during quiet hours, otherwise sends any held stocks followed by the stocks
+func (q *quiet) Send(stocks []Stock) error {
+	if contains(q.start, q.end, q.location, now()) {
+		return q.hold(stocks)
+	}
+
+	if err := q.Release(); err != nil {
+		return err
+	}
+	if len(stocks) == 0 {
+		return nil
+	}
+
+	return q.next.Send(stocks)
+}
+
+// SendText - Sends the text regardless of quiet hours, text is only sent for scheduled digests
+func (q *quiet) SendText(subject string, text string) error {
+	t, ok := q.next.(TextNotifier)
+	if !ok {
+		return fmt.Errorf("text messages aren't supported")
+	}
+
+	return t.SendText(subject, text)
+}
+
+// Release - Sends the stocks held during quiet hours once they're over, one message per alert type. Stocks that
+// fail to send are held again.
+func (q *quiet) Release() error {
+	if contains(q.start, q.end, q.location, now()) {
+		return nil
+	}
+
+	payloads, err := q.queue.Release(q.Channel)
+	if err != nil || len(payloads) == 0 {
+		return err
+	}
+
+	var held []Stock
+	for _, p := range payloads {
+		var s Stock
+		if err = json.Unmarshal([]byte(p), &s); err != nil {
+			log.Printf("%s: dropping unreadable held alert: %s", q.Channel, err) // log and continue with other alerts
+			continue
+		}
+		held = append(held, s)
+	}
+
+	groups := GroupByAlertType(held)
+	for i, stocks := range groups {
+		if err = q.next.Send(stocks); err != nil {
+			for _, unsent := range groups[i:] {
+				if holdErr := q.hold(unsent); holdErr != nil {
+					log.Printf("%s: %s", q.Channel, holdErr)
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hold - Queues the stocks for the channel
+func (q *quiet) hold(stocks []Stock) error {
+	var payloads []string
+	for _, s := range stocks {
+		body, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		payloads = append(payloads, string(body))
+	}
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	return q.queue.Hold(q.Channel, payloads)
+}
+
+// queueKey - Returns the key the channel's held alerts are queued under, the name when set, otherwise the type
+// and a hash of the destination so webhook secrets aren't stored
+func (c Channel) queueKey() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	h := sha256.New()
+	for _, v := range append([]string{c.TopicArn, c.URL, c.From}, c.To...) {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+
+	return c.Type + "-" + hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,92 @@
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeQueue struct {
+	held map[string][]string
+}
+
+func (q *fakeQueue) Hold(channel string, payloads []string) error {
+	q.held[channel] = append(q.held[channel], payloads...)
+	return nil
+}
+
+func (q *fakeQueue) Release(channel string) ([]string, error) {
+	payloads := q.held[channel]
+	delete(q.held, channel)
+	return payloads, nil
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	for _, tc := range []struct {
+		hours    QuietHours
+		at       time.Time
+		expected bool
+	}{
+		{QuietHours{Start: "21:00", End: "07:00"}, time.Date(2021, 1, 4, 22, 0, 0, 0, location), true},
+		{QuietHours{Start: "21:00", End: "07:00"}, time.Date(2021, 1, 4, 6, 59, 0, 0, location), true},
+		{QuietHours{Start: "21:00", End: "07:00"}, time.Date(2021, 1, 4, 7, 0, 0, 0, location), false},
+		{QuietHours{Start: "12:00", End: "13:00"}, time.Date(2021, 1, 4, 12, 30, 0, 0, location), true},
+		{QuietHours{Start: "12:00", End: "13:00", TimeZone: "UTC"}, time.Date(2021, 1, 4, 12, 30, 0, 0, location), false},
+	} {
+		start, end, l, err := tc.hours.window()
+		if err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+		if actual := contains(start, end, l, tc.at); actual != tc.expected {
+			t.Fatalf("Failed with unexpected result for %v at %s: %v", tc.hours, tc.at, actual)
+		}
+	}
+}
+
+func TestFanout_QuietHours_HoldsThenReleases(t *testing.T) {
+	slack := &recorder{status: http.StatusOK}
+	slackServer := httptest.NewServer(slack)
+	defer slackServer.Close()
+	queue := &fakeQueue{held: make(map[string][]string)}
+
+	n, err := NewFanout([]Channel{
+		{Type: ChannelSlack, URL: slackServer.URL, QuietHours: &QuietHours{Start: "20:00", End: "07:00", TimeZone: "UTC"}},
+	}, queue)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	now = func() time.Time { return time.Date(2021, 1, 4, 22, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	if err = n.Send([]Stock{{Symbol: "GNOG", AlertType: AlertTypeGain, Gain: 55}}); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(slack.bodies) != 0 || len(queue.held) != 1 {
+		t.Fatalf("Failed with unexpected sends during quiet hours: %v %v", slack.bodies, queue.held)
+	}
+
+	now = func() time.Time { return time.Date(2021, 1, 5, 7, 30, 0, 0, time.UTC) }
+	if err = n.Send(nil); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(slack.bodies) != 1 || !strings.Contains(slack.bodies[0], "GNOG") || len(queue.held) != 0 {
+		t.Fatalf("Failed with unexpected release: %v %v", slack.bodies, queue.held)
+	}
+}
+
+func TestNewFanout_QuietHoursWithoutQueue_ReturnsError(t *testing.T) {
+	_, err := NewFanout([]Channel{
+		{Type: ChannelSlack, URL: "https://hooks.slack.com/x", QuietHours: &QuietHours{Start: "20:00", End: "07:00"}},
+	}, nil)
+
+	if err == nil {
+		t.Fatalf("Failed with expected error")
+	}
+}
@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLengths - Default message size limit in characters per channel type, channels without a limit aren't split
+var maxLengths = map[string]int{
+	ChannelSNS:     1600, // longest SMS SNS delivers, split by carriers into 70 character segments when it has emoji
+	ChannelSlack:   12000,
+	ChannelDiscord: 2000,
+}
+
+// partPrefixReserve - Characters kept free in each part for the "n/N " prefix
+const partPrefixReserve = len("99/99 ")
+
+// Part - Rendered message and the stocks it covers
+type Part struct {
+	Text   string
+	Stocks []Stock
+}
+
+// RenderParts - Renders the stocks into as few messages within maxLength characters as possible, keeping a
+// stock's lines together when they fit. Parts are prefixed with "1/3", "2/3"... when there's more than one.
+// A maxLength of 0 or less renders a single message.
+func (t *Template) RenderParts(stocks []Stock, maxLength int) ([]Part, error) {
+	whole, err := t.Render(stocks)
+	if err != nil {
+		return nil, err
+	}
+	if maxLength <= 0 || length(whole) <= maxLength {
+		return []Part{{Text: whole, Stocks: stocks}}, nil
+	}
+	if maxLength <= partPrefixReserve {
+		return nil, fmt.Errorf("maxLength %d is too small to split messages", maxLength)
+	}
+
+	limit := maxLength - partPrefixReserve
+	var parts []Part
+	var chunk []Stock
+	var text string
+	for _, s := range stocks {
+		next := append(chunk[:len(chunk):len(chunk)], s)
+		rendered, err := t.Render(next)
+		if err != nil {
+			return nil, err
+		}
+		if length(rendered) <= limit {
+			chunk, text = next, rendered
+			continue
+		}
+
+		if len(chunk) > 0 {
+			parts = append(parts, Part{Text: text, Stocks: chunk})
+		}
+
+		// the stock alone may still be too long, fall back to splitting it by line
+		single, err := t.Render([]Stock{s})
+		if err != nil {
+			return nil, err
+		}
+		if length(single) <= limit {
+			chunk, text = []Stock{s}, single
+			continue
+		}
+		for _, l := range splitLines(single, limit) {
+			parts = append(parts, Part{Text: l, Stocks: []Stock{s}})
+		}
+		chunk, text = nil, ""
+	}
+	if len(chunk) > 0 {
+		parts = append(parts, Part{Text: text, Stocks: chunk})
+	}
+
+	if len(parts) > 1 {
+		for i := range parts {
+			parts[i].Text = fmt.Sprintf("%d/%d %s", i+1, len(parts), parts[i].Text)
+		}
+	}
+
+	return parts, nil
+}
+
+// splitText - Splits free-form text into parts of at most maxLength characters, breaking between lines and
+// prefixing "1/3", "2/3"... when there's more than one. A maxLength of 0 or less returns the text as is.
+func splitText(text string, maxLength int) []string {
+	if maxLength <= 0 || length(text) <= maxLength {
+		return []string{text}
+	}
+
+	parts := splitLines(text, maxLength-partPrefixReserve)
+	if len(parts) > 1 {
+		for i := range parts {
+			parts[i] = fmt.Sprintf("%d/%d %s", i+1, len(parts), parts[i])
+		}
+	}
+
+	return parts
+}
+
+// splitLines - Splits the text into chunks of at most limit characters, breaking between lines where possible
+func splitLines(text string, limit int) []string {
+	var chunks []string
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for length(line) > limit {
+			if sb.Len() > 0 {
+				chunks = append(chunks, sb.String())
+				sb.Reset()
+			}
+			head, tail := cut(line, limit)
+			chunks = append(chunks, head)
+			line = tail
+		}
+		if length(sb.String())+length(line) > limit {
+			chunks = append(chunks, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(line)
+	}
+	if strings.TrimSpace(sb.String()) != "" {
+		chunks = append(chunks, sb.String())
+	}
+
+	return chunks
+}
+
+// cut - Splits s after n characters
+func cut(s string, n int) (string, string) {
+	i := 0
+	for j := range s {
+		if i == n {
+			return s[:j], s[j:]
+		}
+		i++
+	}
+
+	return s, ""
+}
+
+// length - Message length in characters
+func length(s string) int {
+	return utf8.RuneCountInString(s)
+}
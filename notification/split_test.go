@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderParts_WithinLimit_ReturnsSinglePart(t *testing.T) {
+	parts, err := MustLoadTemplate(TemplateSMS).RenderParts(templateStocks, 160)
+
+	if err != nil || len(parts) != 1 || strings.HasPrefix(parts[0].Text, "1/1") {
+		t.Fatalf("Failed with unexpected response: %v %v", parts, err)
+	}
+}
+
+func TestRenderParts_OverLimit_SplitsBetweenStocksInOrder(t *testing.T) {
+	var stocks []Stock
+	for i := 0; i < 5; i++ {
+		stocks = append(stocks, Stock{Symbol: fmt.Sprintf("SYM%d", i), Gain: 50, CurrentPrice: 10, TargetMeanPrice: 15})
+	}
+
+	parts, err := MustLoadTemplate(TemplateSMS).RenderParts(stocks, 120)
+
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("Failed with unexpected parts: %v", parts)
+	}
+	symbol := 0
+	for i, p := range parts {
+		if length(p.Text) > 120 {
+			t.Fatalf("Failed part %d exceeds limit: %d", i, length(p.Text))
+		}
+		if !strings.HasPrefix(p.Text, fmt.Sprintf("%d/3 🚀🚀🚀\n", i+1)) {
+			t.Fatalf("Failed with unexpected part prefix: %q", p.Text)
+		}
+		for _, s := range p.Stocks {
+			if s.Symbol != fmt.Sprintf("SYM%d", symbol) || !strings.Contains(p.Text, s.Symbol+" +50.00%") {
+				t.Fatalf("Failed with unexpected stock order in part %d: %v", i, p)
+			}
+			symbol++
+		}
+	}
+	if symbol != len(stocks) {
+		t.Fatalf("Failed to include every stock: %d", symbol)
+	}
+}
+
+func TestRenderParts_StockOverLimit_SplitsByLine(t *testing.T) {
+	parts, err := MustLoadTemplate(TemplateText).RenderParts(templateStocks, 100)
+
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if len(parts) < 3 {
+		t.Fatalf("Failed with unexpected parts: %v", parts)
+	}
+
+	var text strings.Builder
+	for i, p := range parts {
+		if length(p.Text) > 100 {
+			t.Fatalf("Failed part %d exceeds limit: %d", i, length(p.Text))
+		}
+		prefix := fmt.Sprintf("%d/%d ", i+1, len(parts))
+		text.WriteString(strings.TrimPrefix(p.Text, prefix))
+	}
+	whole, _ := MustLoadTemplate(TemplateText).Render(templateStocks)
+	if text.String() != whole {
+		t.Fatalf("Failed to preserve message expected:%q actual:%q", whole, text.String())
+	}
+}
+
+func TestSplitLines_LongLine_Cuts(t *testing.T) {
+	chunks := splitLines("ab\n"+strings.Repeat("x", 12)+"\ncd\n", 5)
+
+	expected := []string{"ab\n", "xxxxx", "xxxxx", "xx\n", "cd\n"}
+	if fmt.Sprint(chunks) != fmt.Sprint(expected) {
+		t.Fatalf("Failed expected:%q actual:%q", expected, chunks)
+	}
+}
+
+func TestChannelMaxLength(t *testing.T) {
+	tcs := []struct {
+		channel  Channel
+		expected int
+	}{
+		{Channel{Type: ChannelSNS}, 1600},
+		{Channel{Type: ChannelDiscord}, 2000},
+		{Channel{Type: ChannelWebhook}, 0},
+		{Channel{Type: ChannelSNS, MaxLength: 320}, 320},
+		{Channel{Type: ChannelSNS, MaxLength: -1}, 0},
+	}
+
+	for _, tc := range tcs {
+		if actual := tc.channel.maxLength(); actual != tc.expected {
+			t.Fatalf("Failed expected:%d actual:%d", tc.expected, actual)
+		}
+	}
+
+	if _, err := NewNotifier(Channel{Type: ChannelDiscord, URL: "https://discord.com/api/webhooks/1/a", MaxLength: 3}); err == nil {
+		t.Fatal("Failed to reject a maxLength too small to split")
+	}
+}
@@ -0,0 +1,196 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	// TemplateText - Full plain text message, one block per stock
+	TemplateText = "text"
+	// TemplateSMS - Compact plain text message, one line per stock
+	TemplateSMS = "sms"
+	// TemplateMarkdown - Rich Markdown message for chat channels
+	TemplateMarkdown = "markdown"
+	// TemplateHTML - HTML message for email
+	TemplateHTML = "html"
+)
+
+// builtinTemplates - Template bodies shipped with the package, overridable by env or file
+var builtinTemplates = map[string]string{
+	TemplateText: `{{.Header}}{{if .Update}} Update{{end}}{{range .Stocks}}{{update .}}{{followUp .}}
+Symbol: {{.Symbol}}
+Score: {{printf "%.1f" .Score}} ({{scoreFactors .ScoreFactors}})
+Gainz: {{pct .Gain}}
+CurrentPrice: {{fixed .CurrentPrice}}
+TargetHigh: {{fixed .TargetHighPrice}}
+TargetLow: {{fixed .TargetLowPrice}}
+TargetMean: {{fixed .TargetMeanPrice}}
+StrongBuy: {{.StrongBuy}}
+Buy: {{.Buy}}
+Hold: {{.Hold}}
+Sell: {{.Sell}}
+StrongSell: {{.StrongSell}}
+Trend: {{trend .}}
+{{.NewsURL}}{{range .Links}}
+{{.URL}}{{end}}
+{{end}}`,
+
+	TemplateSMS: `{{.Header}}{{if .Update}} Update{{end}}{{range .Stocks}}
+{{.Symbol}} {{signedPct .Gain}} {{currency .CurrentPrice}} tgt {{currency .TargetMeanPrice}} ({{upside .TargetMeanPrice .CurrentPrice}}) {{.StrongBuy}}/{{.Buy}}/{{.Hold}}/{{.Sell}}/{{.StrongSell}}{{if .Update}} was {{signedPct .PreviousGain}}{{end}}{{if .AlertPrice}} from {{currency .AlertPrice}} {{signedPct .Move}}{{end}}{{end}}
+`,
+
+	TemplateMarkdown: `**{{.Header}}{{if .Update}} Update{{end}}**
+{{range .Stocks}}
+**{{.Symbol}}** {{signedPct .Gain}} at {{currency .CurrentPrice}}{{if .Update}} _(was {{signedPct .PreviousGain}} at {{currency .PreviousPrice}})_{{end}}{{if .AlertPrice}} _(alerted at {{currency .AlertPrice}}, {{signedPct .Move}})_{{end}}
+- Score: {{printf "%.1f" .Score}} ({{scoreFactors .ScoreFactors}})
+- Targets: low {{currency .TargetLowPrice}} · mean {{currency .TargetMeanPrice}} ({{upside .TargetMeanPrice .CurrentPrice}}) · high {{currency .TargetHighPrice}} ({{upside .TargetHighPrice .CurrentPrice}})
+- Ratings: strong buy {{.StrongBuy}} · buy {{.Buy}} · hold {{.Hold}} · sell {{.Sell}} · strong sell {{.StrongSell}}
+- Trend: {{trend .}}{{if .NewsURL}}
+- News: {{.NewsURL}}{{end}}{{if .Links}}
+- Links:{{range $i, $l := .Links}}{{if $i}} ·{{end}} [{{$l.Name}}]({{$l.URL}}){{end}}{{end}}
+{{end}}`,
+
+	TemplateHTML: `<h2>{{.Header}}{{if .Update}} Update{{end}}</h2>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Symbol</th><th>Gain</th><th>Price</th><th>Target Low</th><th>Target Mean</th><th>Target High</th><th>Upside</th><th>Ratings (SB/B/H/S/SS)</th><th>Trend</th><th>News</th><th>Links</th></tr>
+{{range .Stocks}}<tr><td>{{.Symbol}}</td><td>{{signedPct .Gain}}{{if .Update}} (was {{signedPct .PreviousGain}}){{end}}{{if .AlertPrice}} ({{signedPct .Move}} since {{currency .AlertPrice}}){{end}}</td><td>{{currency .CurrentPrice}}</td><td>{{currency .TargetLowPrice}}</td><td>{{currency .TargetMeanPrice}}</td><td>{{currency .TargetHighPrice}}</td><td>{{upside .TargetMeanPrice .CurrentPrice}}</td><td>{{.StrongBuy}}/{{.Buy}}/{{.Hold}}/{{.Sell}}/{{.StrongSell}}</td><td>{{trend .}}</td><td>{{if .NewsURL}}<a href="{{.NewsURL}}">news</a>{{end}}</td><td>{{range $i, $l := .Links}}{{if $i}} {{end}}<a href="{{$l.URL}}">{{$l.Name}}</a>{{end}}</td></tr>
+{{end}}</table>
+`,
+}
+
+// templateFuncs - Helper functions available to every template
+var templateFuncs = map[string]interface{}{
+	"pct":          func(v float64) string { return fmt.Sprintf("%.2f%%", v) },
+	"signedPct":    func(v float64) string { return fmt.Sprintf("%+.2f%%", v) },
+	"currency":     currency,
+	"upside":       upside,
+	"fixed":        func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"trend":        formatTrend,
+	"update":       formatUpdate,
+	"followUp":     formatFollowUp,
+	"scoreFactors": formatScoreFactors,
+}
+
+// templateData - Data passed to templates
+type templateData struct {
+	Header string
+	Update bool // every stock is a follow-up to an earlier alert
+	Stocks []Stock
+}
+
+// Template - Message template rendered as text or, for HTML templates, with contextual escaping
+type Template struct {
+	Name string
+	HTML bool
+
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadTemplate - Loads the named template from file when set, otherwise from the NOTIFICATION_TEMPLATE_<NAME>
+// env var, otherwise the built-in template. HTML escaping applies to the html template and .html files.
+func LoadTemplate(name, file string) (*Template, error) {
+	var body string
+	isHTML := name == TemplateHTML
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+		isHTML = strings.EqualFold(filepath.Ext(file), ".html")
+		if name == "" {
+			name = filepath.Base(file)
+		}
+	} else if env := os.Getenv(templateEnv(name)); env != "" {
+		body = env
+	} else if builtin, ok := builtinTemplates[name]; ok {
+		body = builtin
+	} else {
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+
+	t := &Template{
+		Name: name,
+		HTML: isHTML,
+	}
+
+	var err error
+	if isHTML {
+		t.html, err = htmltemplate.New(name).Funcs(templateFuncs).Parse(body)
+	} else {
+		t.text, err = texttemplate.New(name).Funcs(templateFuncs).Parse(body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// MustLoadTemplate - Loads the template and panics on error, for built-in templates
+func MustLoadTemplate(name string) *Template {
+	t, err := LoadTemplate(name, "")
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// Render - Renders the collection of stocks
+func (t *Template) Render(stocks []Stock) (string, error) {
+	data := templateData{
+		Header: header(stocks[0].AlertType),
+		Update: allUpdates(stocks),
+		Stocks: stocks,
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if t.HTML {
+		err = t.html.Execute(&buf, data)
+	} else {
+		err = t.text.Execute(&buf, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", t.Name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// templateEnv - Env var that overrides the named template, e.g. NOTIFICATION_TEMPLATE_SMS
+func templateEnv(name string) string {
+	return "NOTIFICATION_TEMPLATE_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+}
+
+// currency - Formats a dollar amount, e.g. "$12.34"
+func currency(v float64) string {
+	if v < 0 {
+		return fmt.Sprintf("-$%.2f", -v)
+	}
+
+	return fmt.Sprintf("$%.2f", v)
+}
+
+// upside - Formats the percentage move from price to target, e.g. "+25.0%"
+func upside(target, price float64) string {
+	if price == 0 || target == 0 {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("%+.1f%%", (target/price-1)*100)
+}
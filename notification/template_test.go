@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var templateStocks = []Stock{
+	{
+		Symbol:          "GNOG",
+		AlertType:       AlertTypeGain,
+		Gain:            55.5,
+		CurrentPrice:    10,
+		TargetHighPrice: 20,
+		TargetLowPrice:  12,
+		TargetMeanPrice: 15,
+		StrongBuy:       3,
+		Buy:             2,
+		Hold:            1,
+		Score:           72.25,
+		ScoreFactors:    []ScoreFactor{{Name: "gain", Points: 20}},
+		NewsURL:         "https://cutt.ly/gnog",
+		Links:           []Link{{Name: LinkRobinhood, URL: "https://robinhood.com/stocks/GNOG"}},
+	},
+}
+
+func TestRender_Text_MatchesSmsFormat(t *testing.T) {
+	expected := `🚀🚀🚀
+Symbol: GNOG
+Score: 72.2 (gain 20.0)
+Gainz: 55.50%
+CurrentPrice: 10.00
+TargetHigh: 20.00
+TargetLow: 12.00
+TargetMean: 15.00
+StrongBuy: 3
+Buy: 2
+Hold: 1
+Sell: 0
+StrongSell: 0
+Trend: n/a
+https://cutt.ly/gnog
+https://robinhood.com/stocks/GNOG
+`
+
+	actual, err := MustLoadTemplate(TemplateText).Render(templateStocks)
+
+	if err != nil || actual != expected {
+		t.Fatalf("Failed expected:%s actual:%s err:%v", expected, actual, err)
+	}
+}
+
+func TestRender_SMS_OneLinePerStock(t *testing.T) {
+	expected := "🚀🚀🚀\nGNOG +55.50% $10.00 tgt $15.00 (+50.0%) 3/2/1/0/0\n"
+
+	actual, err := MustLoadTemplate(TemplateSMS).Render(templateStocks)
+
+	if err != nil || actual != expected {
+		t.Fatalf("Failed expected:%q actual:%q err:%v", expected, actual, err)
+	}
+}
+
+func TestRender_HTML_EscapesValues(t *testing.T) {
+	stocks := []Stock{{Symbol: "<b>X</b>", NewsURL: "javascript:alert(1)"}}
+
+	actual, err := MustLoadTemplate(TemplateHTML).Render(stocks)
+
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if strings.Contains(actual, "<b>X</b>") || strings.Contains(actual, "javascript:") {
+		t.Fatalf("Failed to escape values: %s", actual)
+	}
+}
+
+func TestLoadTemplate_Overrides(t *testing.T) {
+	os.Setenv("NOTIFICATION_TEMPLATE_SMS", "{{range .Stocks}}{{.Symbol}} {{upside .TargetHighPrice .CurrentPrice}}{{end}}")
+	defer os.Unsetenv("NOTIFICATION_TEMPLATE_SMS")
+
+	actual, err := MustLoadTemplate(TemplateSMS).Render(templateStocks)
+	if err != nil || actual != "GNOG +100.0%" {
+		t.Fatalf("Failed with unexpected env override: %q %v", actual, err)
+	}
+
+	dir, err := ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "digest.html")
+	if err = ioutil.WriteFile(file, []byte(`<p>{{(index .Stocks 0).Symbol}} {{currency -1.5}}</p>`), 0644); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	tmpl, err := LoadTemplate("", file)
+	if err != nil || !tmpl.HTML {
+		t.Fatalf("Failed to load html template file: %v %v", tmpl, err)
+	}
+	actual, err = tmpl.Render(templateStocks)
+	if err != nil || actual != "<p>GNOG -$1.50</p>" {
+		t.Fatalf("Failed with unexpected file override: %q %v", actual, err)
+	}
+
+	if _, err = LoadTemplate("missing", ""); err == nil {
+		t.Fatal("Failed to reject unknown template")
+	}
+}
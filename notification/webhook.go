@@ -0,0 +1,130 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout - How long a webhook post may take, so a hung endpoint doesn't hold up the other channels
+const webhookTimeout = 10 * time.Second
+
+type webhook struct {
+	Type      string
+	URL       string
+	Template  *Template
+	MaxLength int
+	Client    *http.Client // NewNotifier sets a client with webhookTimeout
+
+	linker *linker
+}
+
+// webhookPayload - Body posted to generic webhooks, the versioned Payload with the rendered text
+type webhookPayload struct {
+	Payload
+	Text string `json:"text"`
+}
+
+// Send - Posts the collection of stocks to the webhook in the format expected by its type, posting the parts
+// in order when the message exceeds MaxLength
+func (w *webhook) Send(stocks []Stock) error {
+	if len(stocks) == 0 {
+		return nil
+	}
+
+	parts, err := w.Template.RenderParts(w.linker.apply(stocks), w.MaxLength)
+	if err != nil {
+		return err
+	}
+
+	for i, p := range parts {
+		if err = w.post(p, i+1, len(parts)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SendText - Posts the text to the webhook in the format expected by its type, generic webhooks receive
+// {"subject","text"}
+func (w *webhook) SendText(subject string, text string) error {
+	for _, p := range splitText(text, w.MaxLength) {
+		var body interface{} = struct {
+			Subject string `json:"subject"`
+			Text    string `json:"text"`
+		}{subject, p}
+		if w.Type != ChannelWebhook {
+			body = w.payload(p, Payload{})
+		}
+
+		if err := w.postJSON(body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// post - Posts a single part of the message
+func (w *webhook) post(p Part, part, parts int) error {
+	return w.postJSON(w.payload(p.Text, newPayload(p.Stocks, part, parts)))
+}
+
+// postJSON - Posts the value as JSON and verifies the webhook accepted it
+func (w *webhook) postJSON(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s webhook returned %d: %s", w.Type, resp.StatusCode, b)
+	}
+
+	return nil
+}
+
+type slackPayload struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// payload - Returns the webhook body for the type with the rendered text
+func (w *webhook) payload(text string, payload Payload) interface{} {
+	switch w.Type {
+	case ChannelSlack:
+		// markdown blocks render standard Markdown, text is the notification fallback
+		return slackPayload{
+			Text: text,
+			Blocks: []slackBlock{{
+				Type: "markdown",
+				Text: text,
+			}},
+		}
+	case ChannelDiscord:
+		return struct {
+			Content string `json:"content"`
+		}{text}
+	}
+
+	return webhookPayload{
+		Payload: payload,
+		Text:    text,
+	}
+}
@@ -0,0 +1,173 @@
+package scan
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+	"github.com/lancehumiston/stonk-lambda/screen"
+)
+
+// ProfilesFromEnv - Loads the profiles in PROFILES_FILE, or builds a single default profile from
+// GAIN_THRESHOLD, SNS_TOPIC_ARN, SCREENING_MODE, RULES_FILE, MIN_SCORE, REQUIRE_UPGRADE, REALERT_GAIN_STEP,
+// REALERT_PRICE_STEP, STOP_LOSS_PERCENT, SCORE_WEIGHTS and NOTIFICATION_CHANNELS
+func ProfilesFromEnv() ([]screen.Profile, error) {
+	if profilesFile := os.Getenv("PROFILES_FILE"); profilesFile != "" {
+		return screen.LoadProfiles(profilesFile)
+	}
+
+	threshold := os.Getenv("GAIN_THRESHOLD")
+	gainThresholdPercentage, err := strconv.ParseFloat(threshold, 64)
+	if err != nil {
+		log.Println(err)
+		gainThresholdPercentage = 50
+	}
+	profile := screen.Profile{
+		Name:          screen.DefaultProfileName,
+		GainThreshold: gainThresholdPercentage,
+		SnsTopicArn:   os.Getenv("SNS_TOPIC_ARN"),
+		Mode:          os.Getenv("SCREENING_MODE"),
+		RulesFile:     os.Getenv("RULES_FILE"),
+	}
+	if minScore, err := strconv.ParseFloat(os.Getenv("MIN_SCORE"), 64); err == nil {
+		profile.MinScore = &minScore
+	}
+	if requireUpgrade, err := strconv.ParseBool(os.Getenv("REQUIRE_UPGRADE")); err == nil {
+		profile.RequireUpgrade = requireUpgrade
+	}
+	if step, err := strconv.ParseFloat(os.Getenv("REALERT_GAIN_STEP"), 64); err == nil {
+		profile.ReAlertGainStep = step
+	}
+	if step, err := strconv.ParseFloat(os.Getenv("REALERT_PRICE_STEP"), 64); err == nil {
+		profile.ReAlertPriceStep = step
+	}
+	if stopLoss, err := strconv.ParseFloat(os.Getenv("STOP_LOSS_PERCENT"), 64); err == nil {
+		profile.StopLossPercent = stopLoss
+	}
+	if w := os.Getenv("SCORE_WEIGHTS"); w != "" {
+		weights, err := screen.ParseWeights(w)
+		if err != nil {
+			return nil, err
+		}
+		profile.Weights = &weights
+	}
+	if c := os.Getenv("NOTIFICATION_CHANNELS"); c != "" {
+		channels, err := notification.ParseChannels(c)
+		if err != nil {
+			return nil, err
+		}
+		profile.Channels = channels
+	}
+
+	return screen.CompileProfiles([]screen.Profile{profile})
+}
+
+// StoreFromEnv - Opens the data store selected by DATA_STORE (dynamodb, memory or file), where dynamodb uses
+// TABLE_NAME and file uses DATA_STORE_PATH. When DATA_STORE is unset the TABLE_NAME dynamodb table is used
+// if configured, otherwise nil is returned and alerts aren't de-duplicated.
+func StoreFromEnv() (data.Store, error) {
+	kind := os.Getenv("DATA_STORE")
+	switch kind {
+	case "":
+		if tableName := os.Getenv("TABLE_NAME"); tableName != "" {
+			return data.New(tableName), nil
+		}
+		return nil, nil
+	case data.KindDynamoDB:
+		return data.Open(kind, os.Getenv("TABLE_NAME"))
+	}
+
+	return data.Open(kind, os.Getenv("DATA_STORE_PATH"))
+}
+
+// CandidateStoreFromEnv - Opens the candidate store for digests: the CANDIDATES_TABLE_NAME dynamodb table, or with
+// DATA_STORE set to memory or file, an in-memory store or the CANDIDATES_PATH file (defaulting to DATA_STORE_PATH
+// with a .candidates suffix). Returns nil when no candidate store is configured.
+func CandidateStoreFromEnv() (data.CandidateStore, error) {
+	kind, target := backendFromEnv("CANDIDATES_PATH", ".candidates", "CANDIDATES_TABLE_NAME")
+	if kind == "" {
+		return nil, nil
+	}
+
+	return data.OpenCandidates(kind, target)
+}
+
+// QueueFromEnv - Opens the queue for alerts held during channel quiet hours: the QUEUE_TABLE_NAME dynamodb table, or
+// with DATA_STORE set to memory or file, an in-memory queue or the QUEUE_PATH file (defaulting to DATA_STORE_PATH
+// with a .queue suffix). Returns nil when no queue is configured.
+func QueueFromEnv() (data.Queue, error) {
+	kind, target := backendFromEnv("QUEUE_PATH", ".queue", "QUEUE_TABLE_NAME")
+	if kind == "" {
+		return nil, nil
+	}
+
+	return data.OpenQueue(kind, target)
+}
+
+// KeyStoreFromEnv - Opens the store of upstream API key usage shared by the key pools: the KEYS_TABLE_NAME dynamodb
+// table, or with DATA_STORE set to memory or file, an in-memory store or the KEYS_PATH file (defaulting to
+// DATA_STORE_PATH with a .keys suffix). Returns nil when no key store is configured and usage stays in process memory.
+func KeyStoreFromEnv() (data.KeyStore, error) {
+	kind, target := backendFromEnv("KEYS_PATH", ".keys", "KEYS_TABLE_NAME")
+	if kind == "" {
+		return nil, nil
+	}
+
+	return data.OpenKeys(kind, target)
+}
+
+// backendFromEnv - Resolves the kind and target of a store kept alongside the alerts: with DATA_STORE set to memory
+// or file, an in-memory store or the pathEnv file (defaulting to DATA_STORE_PATH with the suffix), otherwise the
+// tableEnv dynamodb table. Returns an empty kind when the store isn't configured.
+func backendFromEnv(pathEnv string, suffix string, tableEnv string) (string, string) {
+	switch kind := os.Getenv("DATA_STORE"); kind {
+	case data.KindMemory:
+		return kind, ""
+	case data.KindFile:
+		path := os.Getenv(pathEnv)
+		if path == "" && os.Getenv("DATA_STORE_PATH") != "" {
+			path = os.Getenv("DATA_STORE_PATH") + suffix
+		}
+		return kind, path
+	}
+
+	if tableName := os.Getenv(tableEnv); tableName != "" {
+		return data.KindDynamoDB, tableName
+	}
+
+	return "", ""
+}
+
+// CalendarFromEnv - Returns the NYSE calendar with the built-in special closures, plus those in MARKET_CALENDAR_FILE
+// when set
+func CalendarFromEnv() (*market.Calendar, error) {
+	if path := os.Getenv("MARKET_CALENDAR_FILE"); path != "" {
+		return market.LoadCalendar(path)
+	}
+
+	return market.NewCalendar()
+}
+
+// SessionsFromEnv - Returns the comma separated SCAN_SESSIONS scans run in, defaulting to pre, regular and post so
+// scans are skipped overnight, on weekends and on holidays
+func SessionsFromEnv() ([]market.Session, error) {
+	value := os.Getenv("SCAN_SESSIONS")
+	if value == "" {
+		return []market.Session{market.SessionPre, market.SessionRegular, market.SessionPost}, nil
+	}
+
+	var sessions []market.Session
+	for _, v := range strings.Split(value, ",") {
+		session, err := market.ParseSession(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
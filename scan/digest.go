@@ -0,0 +1,220 @@
+package scan
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/notification"
+)
+
+// DigestEntry - Everything the scanner saw of a symbol for a profile since the digest start
+type DigestEntry struct {
+	Symbol     string   `json:"symbol"`
+	Profile    string   `json:"profile,omitempty"` // empty when the symbol's analysis failed
+	Direction  string   `json:"direction"`
+	Runs       int      `json:"runs"` // runs that saw the symbol
+	Alerted    bool     `json:"alerted"`
+	Gates      []string `json:"gates,omitempty"` // gates that rejected the symbol, first seen first
+	Reason     string   `json:"reason,omitempty"`
+	Gain       float64  `json:"gain"`       // gain when alerted, otherwise the largest move seen
+	AlertPrice float64  `json:"alertPrice"` // price when first alerted
+	Close      float64  `json:"close"`      // closing price of alerted symbols, zero when unknown
+}
+
+// CloseChange - Percentage move from the alert price to the close
+func (e DigestEntry) CloseChange() float64 {
+	if e.AlertPrice == 0 || e.Close == 0 {
+		return 0
+	}
+
+	return (e.Close/e.AlertPrice - 1) * 100
+}
+
+// Digest - Summary of the candidates seen since a time
+type Digest struct {
+	Since   time.Time     `json:"since"`
+	Entries []DigestEntry `json:"entries"` // alerted first, then by symbol
+}
+
+// BuildDigest - Groups the candidates by symbol, profile and direction
+func BuildDigest(since time.Time, candidates []data.Candidate) Digest {
+	entries := make(map[string]*DigestEntry)
+	var keys []string
+	for _, c := range candidates {
+		key := strings.Join([]string{c.Symbol, c.Profile, c.Direction}, "#")
+		e, ok := entries[key]
+		if !ok {
+			e = &DigestEntry{
+				Symbol:    c.Symbol,
+				Profile:   c.Profile,
+				Direction: c.Direction,
+			}
+			entries[key] = e
+			keys = append(keys, key)
+		}
+
+		e.Runs++
+		if c.Alerted {
+			if !e.Alerted {
+				e.AlertPrice = c.Price
+				e.Gain = c.Gain
+			}
+			e.Alerted = true
+			continue
+		}
+
+		if !e.Alerted && abs(c.Gain) > abs(e.Gain) {
+			e.Gain = c.Gain
+		}
+		e.Reason = c.Reason
+		if !contains(e.Gates, c.Gate) {
+			e.Gates = append(e.Gates, c.Gate)
+		}
+	}
+
+	d := Digest{Since: since}
+	for _, k := range keys {
+		d.Entries = append(d.Entries, *entries[k])
+	}
+	sort.SliceStable(d.Entries, func(i, j int) bool {
+		if d.Entries[i].Alerted != d.Entries[j].Alerted {
+			return d.Entries[i].Alerted
+		}
+		return d.Entries[i].Symbol < d.Entries[j].Symbol
+	})
+
+	return d
+}
+
+// Profile - Returns the digest entries for the profile, including symbols whose analysis failed
+func (d Digest) Profile(name string) Digest {
+	filtered := Digest{Since: d.Since}
+	for _, e := range d.Entries {
+		if e.Profile == name || e.Profile == "" {
+			filtered.Entries = append(filtered.Entries, e)
+		}
+	}
+
+	return filtered
+}
+
+// Format - Formats the digest as plain text
+func (d Digest) Format() string {
+	var alerted, rejected []DigestEntry
+	for _, e := range d.Entries {
+		if e.Alerted {
+			alerted = append(alerted, e)
+		} else {
+			rejected = append(rejected, e)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 Digest since %s: %d seen, %d alerted\n", d.Since.UTC().Format("2006-01-02 15:04 MST"), len(d.Entries), len(alerted)))
+	if len(alerted) > 0 {
+		sb.WriteString("\nAlerted:\n")
+	}
+	for _, e := range alerted {
+		closed := "close n/a"
+		if e.Close != 0 {
+			closed = fmt.Sprintf("closed %.2f (%+.2f%%)", e.Close, e.CloseChange())
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %.2f%% at %.2f, %s\n", e.Symbol, e.Direction, e.Gain, e.AlertPrice, closed))
+	}
+	if len(rejected) > 0 {
+		sb.WriteString("\nRejected:\n")
+	}
+	for _, e := range rejected {
+		sb.WriteString(fmt.Sprintf("%s %.2f%% %s x%d: %s\n", e.Symbol, e.Gain, strings.Join(e.Gates, ","), e.Runs, e.Reason))
+	}
+
+	return sb.String()
+}
+
+// Digest - Summarizes the candidates seen since the time, with the closing price of alerted symbols, and sends each
+// profile its digest
+func (s *scanner) Digest(since time.Time) (Digest, error) {
+	if s.Candidates == nil {
+		return Digest{}, fmt.Errorf("digest requires a candidate store")
+	}
+
+	candidates, err := s.Candidates.Candidates(since)
+	if err != nil {
+		return Digest{}, err
+	}
+
+	d := BuildDigest(since, candidates)
+	s.closes(&d)
+
+	if s.DryRun {
+		return d, nil
+	}
+
+	var sendErr error
+	for _, profile := range s.Profiles {
+		digest := d.Profile(profile.Name)
+		if len(digest.Entries) == 0 {
+			continue
+		}
+
+		notifier, err := notification.NewFanout(profile.NotificationChannels(), s.Queue)
+		if err == nil {
+			err = notifier.SendText("Stonk digest", digest.Format())
+		}
+		if err != nil {
+			log.Printf("%s: %s", profile.Name, err) // log and continue sending to other profiles
+			sendErr = err
+		}
+	}
+
+	return d, sendErr
+}
+
+// closes - Sets the latest close of every alerted symbol, failures are logged and leave the close unknown
+func (s *scanner) closes(d *Digest) {
+	if s.History == nil {
+		return
+	}
+
+	closes := make(map[string]float64)
+	for i := range d.Entries {
+		e := &d.Entries[i]
+		if !e.Alerted {
+			continue
+		}
+
+		c, ok := closes[e.Symbol]
+		if !ok {
+			history, err := s.History.GetDailyCloses(e.Symbol, d.Since, time.Now())
+			if err != nil {
+				log.Println(err)
+			} else if len(history) > 0 {
+				c = history[len(history)-1].Close
+			}
+			closes[e.Symbol] = c
+		}
+		e.Close = c
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+func contains(items []string, v string) bool {
+	for _, i := range items {
+		if i == v {
+			return true
+		}
+	}
+
+	return false
+}
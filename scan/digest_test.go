@@ -0,0 +1,112 @@
+package scan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+	"github.com/lancehumiston/stonk-lambda/screen"
+)
+
+// fakeHistory - PriceHistoryProvider returning a fixed close per symbol
+type fakeHistory map[string]float64
+
+func (f fakeHistory) GetDailyCloses(symbol string, from time.Time, to time.Time) ([]market.DailyClose, error) {
+	if c, ok := f[symbol]; ok {
+		return []market.DailyClose{{Date: from, Close: c}}, nil
+	}
+
+	return nil, nil
+}
+
+var digestStart = time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+
+func TestRecord_Digest_SummarizesRuns(t *testing.T) {
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: "moonshot"}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	s := New(profiles, nil)
+	s.Candidates = data.NewMemoryCandidates()
+	s.History = fakeHistory{"GNOG": 11}
+
+	runs := []Result{
+		{
+			Direction: market.Gainers,
+			Rejections: []Rejection{
+				{Symbol: "GNOG", Profile: "moonshot", Gate: "gainThreshold", Reason: "too low", Gain: 20},
+				{Symbol: "MOON", Profile: "moonshot", Gate: "noSellRating", Reason: "MOON has sell:1", Gain: 70},
+				{Symbol: "DUD", Gate: GateAnalysis, Reason: "404"},
+			},
+		},
+		{
+			Direction: market.Gainers,
+			Alerts: map[string][]notification.Stock{
+				"moonshot": {{Symbol: "GNOG", Gain: 55, CurrentPrice: 10, Score: 80}},
+			},
+			Rejections: []Rejection{
+				{Symbol: "MOON", Profile: "moonshot", Gate: GateNews, Reason: "cuttly down", Gain: 75},
+			},
+		},
+	}
+	for i, r := range runs {
+		if err = s.record(string(rune('a'+i)), r); err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+	}
+
+	s.DryRun = true
+	d, err := s.Digest(digestStart)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if len(d.Entries) != 3 {
+		t.Fatalf("Failed with unexpected entries: %v", d.Entries)
+	}
+	gnog, dud, moon := d.Entries[0], d.Entries[1], d.Entries[2]
+	if gnog.Symbol != "GNOG" || !gnog.Alerted || gnog.Runs != 2 || gnog.Gain != 55 || gnog.AlertPrice != 10 || gnog.Close != 11 {
+		t.Fatalf("Failed with unexpected alerted entry: %+v", gnog)
+	}
+	if dud.Symbol != "DUD" || dud.Profile != "" || dud.Gates[0] != GateAnalysis {
+		t.Fatalf("Failed with unexpected analysis entry: %+v", dud)
+	}
+	if moon.Symbol != "MOON" || moon.Alerted || moon.Runs != 2 || moon.Gain != 75 || strings.Join(moon.Gates, ",") != "noSellRating,news" || moon.Reason != "cuttly down" {
+		t.Fatalf("Failed with unexpected rejected entry: %+v", moon)
+	}
+
+	text := d.Profile("moonshot").Format()
+	for _, expected := range []string{
+		"3 seen, 1 alerted",
+		"GNOG gainers 55.00% at 10.00, closed 11.00 (+10.00%)",
+		"MOON 75.00% noSellRating,news x2: cuttly down",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Fatalf("Failed to format %q in: %s", expected, text)
+		}
+	}
+	if len(d.Profile("other").Entries) != 1 {
+		t.Fatal("Failed to limit the digest to the profile and analysis failures")
+	}
+}
+
+func TestGate(t *testing.T) {
+	profile := screen.Profile{Name: "moonshot", GainThreshold: gainThreshold}
+	profiles, err := screen.CompileProfiles([]screen.Profile{profile})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	_, err = validateAgainstTresholds(&profiles[0], market.Gainers, "GNOG", market.Analysis{})
+
+	if actual := gate(err); actual != "gainThreshold" {
+		t.Fatalf("Failed with unexpected gate: %s", actual)
+	}
+	if actual := gate(errors.New("boom")); actual != GateScreen {
+		t.Fatalf("Failed with unexpected gate: %s", actual)
+	}
+}
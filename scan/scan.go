@@ -0,0 +1,541 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+	"github.com/lancehumiston/stonk-lambda/screen"
+	"github.com/lancehumiston/stonk-lambda/url"
+)
+
+// Rejection - Symbol that failed a profile's screen, or failed analysis when Profile is empty
+type Rejection struct {
+	Symbol  string  `json:"symbol"`
+	Profile string  `json:"profile,omitempty"`
+	Gate    string  `json:"gate"` // rule name, or the notFound, analysis, dedupe or news step that rejected the symbol
+	Reason  string  `json:"reason"`
+	Gain    float64 `json:"gain"`
+	Price   float64 `json:"price"`
+	Score   float64 `json:"score"`
+}
+
+const (
+	// GateAnalysis - The symbol's analysis couldn't be fetched
+	GateAnalysis = "analysis"
+	// GateDedupe - The symbol was already alerted
+	GateDedupe = "dedupe"
+	// GateNotFound - The providers don't know the symbol
+	GateNotFound = "notFound"
+	// GateNews - The symbol's news couldn't be fetched
+	GateNews = "news"
+	// GateScreen - The symbol failed an unnamed screening rule
+	GateScreen = "screen"
+)
+
+// Result - Outcome of screening a collection of symbols
+type Result struct {
+	Direction  market.Direction                `json:"direction"`
+	Alerts     map[string][]notification.Stock `json:"alerts"` // keyed by profile name, best score first
+	Rejections []Rejection                     `json:"rejections"`
+	// Errors - Upstream failures by market.ErrorKind, symbolNotFound counts skipped symbols and every other kind a
+	// broken provider
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// count - Counts the upstream failures by kind
+func (r *Result) count(errs ...error) {
+	for _, err := range errs {
+		if r.Errors == nil {
+			r.Errors = make(map[string]int)
+		}
+		r.Errors[market.ErrorKind(err)]++
+	}
+}
+
+// SkippedSymbols - Returns the number of symbols the providers didn't know
+func (r Result) SkippedSymbols() int {
+	return r.Errors[market.ErrorKind(market.ErrSymbolNotFound)]
+}
+
+// ProviderErrors - Returns the number of failed upstream calls other than unknown symbols
+func (r Result) ProviderErrors() int {
+	n := 0
+	for _, v := range r.Errors {
+		n += v
+	}
+
+	return n - r.SkippedSymbols()
+}
+
+type scanner struct {
+	Profiles   []screen.Profile
+	Store      data.Store                  // dedupe is skipped when nil
+	Candidates data.CandidateStore         // candidates aren't recorded when nil
+	History    market.PriceHistoryProvider // closing prices for digests
+	Queue      data.Queue                  // alerts held for channels in quiet hours
+	DryRun     bool                        // skips data store writes and notifications
+}
+
+// New - Public constructor for scanner
+func New(profiles []screen.Profile, store data.Store) *scanner {
+	if len(profiles) == 0 {
+		log.Panic("profiles cannot be empty")
+	}
+
+	return &scanner{
+		Profiles: profiles,
+		Store:    store,
+		History:  market.NewYahooPriceHistory(),
+	}
+}
+
+// Run - Screens the top movers in the direction, notifies each profile of its alerts and records every candidate
+// for the digest
+func (s *scanner) Run(direction market.Direction) (Result, error) {
+	runID := time.Now().UTC().Format(time.RFC3339)
+	symbols, errs := s.GetTopMovers(direction)
+
+	result := s.Screen(direction, symbols)
+	result.count(errs...)
+	for _, r := range result.Rejections {
+		log.Println(r)
+	}
+
+	if err := s.record(runID, result); err != nil {
+		log.Println(err) // the digest misses this run, alerts still go out
+	}
+
+	return result, s.Notify(result)
+}
+
+// record - Persists the run's alerted and rejected candidates
+func (s *scanner) record(runID string, result Result) error {
+	if s.Candidates == nil || s.DryRun {
+		return nil
+	}
+
+	var candidates []data.Candidate
+	for profile, stocks := range result.Alerts {
+		for _, stock := range stocks {
+			candidates = append(candidates, data.Candidate{
+				RunID:           runID,
+				Symbol:          stock.Symbol,
+				Profile:         profile,
+				Direction:       string(result.Direction),
+				Alerted:         true,
+				Gain:            stock.Gain,
+				Price:           stock.CurrentPrice,
+				TargetMeanPrice: stock.TargetMeanPrice,
+				TargetHighPrice: stock.TargetHighPrice,
+				Score:           stock.Score,
+			})
+		}
+	}
+	for _, r := range result.Rejections {
+		candidates = append(candidates, data.Candidate{
+			RunID:     runID,
+			Symbol:    r.Symbol,
+			Profile:   r.Profile,
+			Direction: string(result.Direction),
+			Gate:      r.Gate,
+			Reason:    r.Reason,
+			Gain:      r.Gain,
+			Price:     r.Price,
+			Score:     r.Score,
+		})
+	}
+
+	return s.Candidates.InsertCandidates(candidates)
+}
+
+// GetTopMovers - Collects the top movers in the direction from every provider, along with the errors of the
+// providers that failed
+func (s *scanner) GetTopMovers(direction market.Direction) ([]string, []error) {
+	var symbols []string
+	var errs []error
+	providers := market.GetTopMoversProviders()
+
+	ch := make(chan []string, len(providers))
+	errCh := make(chan error, cap(ch))
+	for _, v := range providers {
+		go func(provider market.TopMoversProvider, ch chan<- []string, errCh chan<- error) {
+			topMovers, err := provider.GetTopMovers(direction)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			ch <- topMovers
+		}(v, ch, errCh)
+	}
+
+	for i := 0; i < cap(ch); i++ {
+		select {
+		case topMovers := <-ch:
+			symbols = append(symbols, topMovers...)
+		case err := <-errCh:
+			log.Println(err) // log and continue with data from other providers
+			errs = append(errs, err)
+		}
+	}
+
+	return symbols, errs
+}
+
+// getAnalysis - Market data lookup, replaced in tests
+var getAnalysis = market.GetAnalysis
+
+// getCompanyName - Company name lookup for news, replaced in tests
+var getCompanyName = market.GetCompanyName
+
+// shortenURL - Link shortener for news, replaced in tests
+var shortenURL = url.GetShortenedAlias
+
+// alertTypes - Notification alert type per top movers direction
+var alertTypes = map[market.Direction]string{
+	market.Gainers: notification.AlertTypeGain,
+	market.Losers:  notification.AlertTypeDip,
+}
+
+// validateAgainstTresholds - Verifies that the symbol meets the profile's notification thresholds and returns its score
+func validateAgainstTresholds(profile *screen.Profile, direction market.Direction, symbol string, analysis market.Analysis) (screen.Score, error) {
+	return profile.Screen(screen.Subject{
+		Symbol:        symbol,
+		Direction:     direction,
+		Price:         analysis.Price,
+		Rating:        analysis.Rating,
+		Trend:         analysis.Trend,
+		FinancialData: analysis.FinancialData,
+	})
+}
+
+// alert - Stock that passed a profile's screen
+type alert struct {
+	profile string
+	stock   notification.Stock
+}
+
+// symbolResult - Outcome of screening a single symbol against every profile
+type symbolResult struct {
+	alerts     []alert
+	rejections []Rejection
+	err        error // analysis failure
+}
+
+// Screen - Filters the collection of symbols to those that meet the notificaiton criteria of each profile
+func (s *scanner) Screen(direction market.Direction, symbols []string) Result {
+	result := Result{
+		Direction: direction,
+		Alerts:    make(map[string][]notification.Stock),
+	}
+
+	uniqueSymbols := unique(normalize(symbols))
+	ch := make(chan symbolResult, len(uniqueSymbols))
+	for _, v := range uniqueSymbols {
+		go func(symbol string, ch chan<- symbolResult) {
+			ch <- s.screenSymbol(direction, symbol)
+		}(v, ch)
+	}
+
+	for i := 0; i < cap(ch); i++ {
+		r := <-ch
+		result.Rejections = append(result.Rejections, r.rejections...)
+		if r.err != nil {
+			result.count(r.err)
+		}
+		for _, a := range r.alerts {
+			result.Alerts[a.profile] = append(result.Alerts[a.profile], a.stock)
+		}
+	}
+
+	// best candidate first
+	for _, stocks := range result.Alerts {
+		sort.SliceStable(stocks, func(i, j int) bool {
+			return stocks[i].Score > stocks[j].Score
+		})
+	}
+	sort.SliceStable(result.Rejections, func(i, j int) bool {
+		return result.Rejections[i].Symbol < result.Rejections[j].Symbol
+	})
+
+	return result
+}
+
+// screenSymbol - Screens the symbol against every profile using a single analysis lookup
+func (s *scanner) screenSymbol(direction market.Direction, symbol string) symbolResult {
+	var r symbolResult
+	var gainPercentage, currentPrice float64
+	var newsURL string
+	var newsErr error
+	var shortened bool
+	scores := make(map[string]float64)
+	reject := func(profile string, gate string, err error) {
+		r.rejections = append(r.rejections, Rejection{
+			Symbol:  symbol,
+			Profile: profile,
+			Gate:    gate,
+			Reason:  err.Error(),
+			Gain:    gainPercentage,
+			Price:   currentPrice,
+			Score:   scores[profile],
+		})
+	}
+
+	analysis, err := getAnalysis(symbol)
+	if errors.Is(err, market.ErrSymbolNotFound) {
+		reject("", GateNotFound, err)
+		r.err = err
+		return r
+	}
+	if err != nil {
+		reject("", GateAnalysis, err)
+		r.err = err
+		return r
+	}
+	price, rating, financialData := analysis.Price, analysis.Rating, analysis.FinancialData
+	trendChange, _ := analysis.Trend.Change()
+	gainPercentage, currentPrice = price.MarketChange.Percent, financialData.CurrentPrice.USD
+
+	for i := range s.Profiles {
+		profile := &s.Profiles[i]
+		score, err := validateAgainstTresholds(profile, direction, symbol, analysis)
+		scores[profile.Name] = score.Total
+		if err != nil {
+			reject(profile.Name, gate(err), err)
+			continue
+		}
+
+		// news is looked up once per symbol, before claiming so a failed lookup never leaves a claim behind
+		if newsURL == "" && newsErr == nil {
+			newsURL, newsErr = getNewsURL(symbol)
+		}
+		if newsErr != nil {
+			reject(profile.Name, GateNews, newsErr)
+			continue
+		}
+
+		claim, err := s.dedupe(profile, symbol, direction, gainPercentage, financialData.CurrentPrice.USD)
+		if err != nil {
+			reject(profile.Name, GateDedupe, err)
+			continue
+		}
+
+		// shortened only once a claim is won so blocked symbols don't use up the shortener's quota, shortening
+		// can't fail the alert
+		if !shortened {
+			newsURL, shortened = shortenNewsURL(symbol, newsURL), true
+		}
+
+		r.alerts = append(r.alerts, alert{
+			profile: profile.Name,
+			stock: notification.Stock{
+				Symbol:          symbol,
+				AlertType:       alertTypes[direction],
+				Gain:            gainPercentage,
+				CurrentPrice:    financialData.CurrentPrice.USD,
+				TargetLowPrice:  financialData.TargetLowPrice.USD,
+				TargetHighPrice: financialData.TargetHighPrice.USD,
+				TargetMeanPrice: financialData.TargetMeanPrice.USD,
+				StrongBuy:       rating.StrongBuy,
+				Buy:             rating.Buy,
+				Hold:            rating.Hold,
+				Sell:            rating.Sell,
+				StrongSell:      rating.StrongSell,
+				Score:           score.Total,
+				ScoreFactors:    scoreFactors(score),
+				TrendPeriod:     trendChange.From,
+				BullishChange:   trendChange.Bullish,
+				BearishChange:   trendChange.Bearish,
+				Update:          claim.Update,
+				PreviousGain:    claim.Previous.Percentage,
+				PreviousPrice:   claim.Previous.Price,
+				NewsURL:         newsURL,
+			},
+		})
+	}
+
+	return r
+}
+
+// gate - Returns the name of the screening rule that rejected the symbol
+func gate(err error) string {
+	var ruleErr *screen.RuleError
+	if errors.As(err, &ruleErr) && ruleErr.Rule != "" {
+		return ruleErr.Rule
+	}
+
+	return GateScreen
+}
+
+// dedupe - Returns an error when the symbol was already alerted for the profile and direction and the profile's
+// re-alert policy doesn't allow a follow-up, otherwise claims the alert so overlapping runs can't both notify.
+// Dry runs only check for an existing record.
+func (s *scanner) dedupe(profile *screen.Profile, symbol string, direction market.Direction, percentage float64, price float64) (data.ClaimResult, error) {
+	if s.Store == nil {
+		return data.ClaimResult{Won: true}, nil
+	}
+
+	key := data.Key(symbol, profile.Name, string(direction))
+	if s.DryRun {
+		exists, err := s.Store.Exists(key)
+		if err != nil {
+			return data.ClaimResult{}, err
+		}
+		if exists {
+			return data.ClaimResult{}, fmt.Errorf("record exists for %s", key)
+		}
+		return data.ClaimResult{Won: true}, nil
+	}
+
+	var policy data.ReAlertPolicy
+	if direction == market.Gainers {
+		// follow-ups are for stocks that keep running, a deepening dip isn't a better reversal candidate
+		policy = data.ReAlertPolicy{
+			GainStep:  profile.ReAlertGainStep,
+			PriceStep: profile.ReAlertPriceStep,
+		}
+	}
+
+	claim, err := s.Store.Claim(data.Record{
+		Ticker:     symbol,
+		Profile:    profile.Name,
+		Direction:  string(direction),
+		Percentage: percentage,
+		Price:      price,
+	}, policy)
+	if err != nil {
+		return claim, err
+	}
+	if !claim.Won {
+		return claim, fmt.Errorf("record exists for %s", key)
+	}
+
+	return claim, nil
+}
+
+// getNewsURL - Returns the news URL for the symbol's company
+func getNewsURL(symbol string) (string, error) {
+	companyName, err := getCompanyName(symbol)
+	if err != nil {
+		return "", err
+	}
+
+	return market.GetNews(companyName)
+}
+
+// shortenNewsURL - Returns the shortened news URL, falling back to the full URL when it can't be shortened, e.g.
+// cutt.ly is over its quota
+func shortenNewsURL(symbol string, newsURL string) string {
+	alias, err := shortenURL(newsURL)
+	if err != nil || alias == "" {
+		log.Printf("%s: sending the full news URL: %v", symbol, err)
+		return newsURL
+	}
+
+	return alias
+}
+
+// Notify - Sends each profile's alerts to its notification channels along with any alerts held during quiet hours
+// that have ended, a failure for one profile doesn't block the others
+func (s *scanner) Notify(result Result) error {
+	if s.DryRun {
+		return nil
+	}
+
+	var sendErr error
+	for _, profile := range s.Profiles {
+		// profiles without alerts still send the alerts held for channels whose quiet hours have ended
+		stocks := result.Alerts[profile.Name]
+
+		notifier, err := notification.NewFanout(profile.NotificationChannels(), s.Queue)
+		if err != nil {
+			log.Printf("%s: %s", profile.Name, err)
+			sendErr = err
+			continue
+		}
+		// a message has a single header, so e.g. follow-ups send stop-losses apart from reached targets
+		groups := notification.GroupByAlertType(stocks)
+		if len(groups) == 0 {
+			groups = append(groups, nil)
+		}
+		for _, group := range groups {
+			if err := notifier.Send(group); err != nil {
+				log.Printf("%s: %s", profile.Name, err) // log and continue sending to other profiles
+				sendErr = err
+			}
+		}
+	}
+
+	return sendErr
+}
+
+// NotifyDegraded - Sends the summary of the degraded providers to every profile's notification channels
+func (s *scanner) NotifyDegraded(degraded []market.Degraded) error {
+	if s.DryRun || len(degraded) == 0 {
+		return nil
+	}
+
+	var sendErr error
+	for _, profile := range s.Profiles {
+		notifier, err := notification.NewFanout(profile.NotificationChannels(), s.Queue)
+		if err == nil {
+			err = notifier.SendText("Stonk degraded providers", market.FormatDegraded(degraded))
+		}
+		if err != nil {
+			log.Printf("%s: %s", profile.Name, err) // log and continue sending to other profiles
+			sendErr = err
+		}
+	}
+
+	return sendErr
+}
+
+func scoreFactors(score screen.Score) []notification.ScoreFactor {
+	var factors []notification.ScoreFactor
+	for _, f := range score.Factors {
+		factors = append(factors, notification.ScoreFactor{
+			Name:   f.Name,
+			Points: f.Points,
+		})
+	}
+
+	return factors
+}
+
+// normalize - Upper cases and trims the symbols since providers don't agree on casing, empty symbols are dropped
+func normalize(symbols []string) []string {
+	var normalized []string
+	for _, v := range symbols {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			normalized = append(normalized, v)
+		}
+	}
+
+	return normalized
+}
+
+func unique(items []string) []string {
+	if items == nil || len(items) == 0 {
+		return items
+	}
+
+	var uniqueItems []string
+	set := make(map[string]struct{})
+	for _, v := range items {
+		if _, ok := set[v]; ok {
+			continue
+		}
+
+		uniqueItems = append(uniqueItems, v)
+		set[v] = struct{}{}
+	}
+
+	return uniqueItems
+}
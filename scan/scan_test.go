@@ -0,0 +1,486 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/screen"
+)
+
+const gainThreshold float64 = 50
+
+func TestUnique_Duplicates_ReturnsUniqueItems(t *testing.T) {
+	items := []string{
+		"a",
+		"b",
+		"c",
+		"b",
+		"c",
+		"d",
+	}
+	expected := []string{
+		"a",
+		"b",
+		"c",
+		"d",
+	}
+
+	result := unique(items)
+
+	if !IsEqual(result, expected) {
+		t.Fatalf("Failed expected:%v actual:%v", expected, result)
+	}
+}
+
+func IsEqual(a, b []string) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type testCase struct {
+	name          string
+	symbol        string
+	price         market.Price
+	rating        market.RecommendationRating
+	financialData market.FinancialData
+	expected      error
+}
+
+var validateAgainstTresholdsTCs = []testCase{
+	{
+		name:   "Success targetMeanPrice > 1.5*currentPrice w/ buy rating",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 9,
+			},
+		},
+		rating: market.RecommendationRating{
+			Buy: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 10,
+			},
+			TargetMeanPrice: market.Currency{
+				USD: 15,
+			},
+		},
+		expected: nil,
+	},
+	{
+		name:   "Success targetMeanPrice > 1.5*currentPrice w/ strongBuy rating",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 9,
+			},
+		},
+		rating: market.RecommendationRating{
+			StrongBuy: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 10,
+			},
+			TargetMeanPrice: market.Currency{
+				USD: 15,
+			},
+		},
+		expected: nil,
+	},
+	{
+		name:   "Fail gainPercentage below threshold",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold - 1,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 10,
+			},
+		},
+		rating: market.RecommendationRating{
+			StrongBuy: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 15,
+			},
+			TargetMeanPrice: market.Currency{},
+		},
+		expected: errors.New("GNOG gain:49.00 is not above threshold:50.00"),
+	},
+	{
+		name:   "Fail preMarketPrice is above currentPrice",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 15,
+			},
+		},
+		rating: market.RecommendationRating{
+			StrongBuy: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 10,
+			},
+			TargetMeanPrice: market.Currency{},
+		},
+		expected: errors.New("GNOG preMarketPrice:15.00 is above currentPrice:10.00"),
+	},
+	{
+		name:   "Fail no targetMeanPrice",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 10,
+			},
+		},
+		rating: market.RecommendationRating{
+			Buy: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 15,
+			},
+			TargetMeanPrice: market.Currency{},
+		},
+		expected: errors.New("GNOG targetMeanPrice:0.00 is less than fiftyPercentGainPrice:22.50 currentPrice:15.00"),
+	},
+	{
+		name:   "Fail sell",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 9,
+			},
+		},
+		rating: market.RecommendationRating{
+			Sell: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 10,
+			},
+			TargetMeanPrice: market.Currency{
+				USD: 15,
+			},
+		},
+		expected: errors.New("GNOG has sell:1 strongSell:0 rating"),
+	},
+	{
+		name:   "Fail strongSell",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 9,
+			},
+		},
+		rating: market.RecommendationRating{
+			StrongSell: 1,
+		},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 10,
+			},
+			TargetMeanPrice: market.Currency{
+				USD: 15,
+			},
+		},
+		expected: errors.New("GNOG has sell:0 strongSell:1 rating"),
+	},
+	{
+		name:   "Fail no buy or strongBuy",
+		symbol: "GNOG",
+		price: market.Price{
+			MarketChange: market.Percent{
+				Percent: gainThreshold,
+			},
+			PreMarketPrice: market.Currency{
+				USD: 9,
+			},
+		},
+		rating: market.RecommendationRating{},
+		financialData: market.FinancialData{
+			CurrentPrice: market.Currency{
+				USD: 10,
+			},
+			TargetMeanPrice: market.Currency{
+				USD: 15,
+			},
+		},
+		expected: errors.New("GNOG has buy:0 strongBuy:0 rating"),
+	},
+}
+
+func TestValidateAgainstTresholds(t *testing.T) {
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName, GainThreshold: gainThreshold}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	for _, tc := range validateAgainstTresholdsTCs {
+		_, actual := validateAgainstTresholds(&profiles[0], market.Gainers, tc.symbol, market.Analysis{
+			Price:         tc.price,
+			Rating:        tc.rating,
+			FinancialData: tc.financialData,
+		})
+
+		if fmt.Sprintf("%v", actual) != fmt.Sprintf("%v", tc.expected) {
+			t.Fatalf("%s expected: %v, actual: %v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestDedupe_MemoryStore_RejectsSecondAlert(t *testing.T) {
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	s := New(profiles, data.NewMemory())
+	moonshot := &screen.Profile{Name: "moonshot"}
+
+	if _, err = s.dedupe(moonshot, "GNOG", market.Gainers, 55, 10); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	_, err = s.dedupe(moonshot, "GNOG", market.Gainers, 60, 11)
+	if err == nil || err.Error() != "record exists for GNOG#moonshot" {
+		t.Fatalf("Failed with unexpected response: %v", err)
+	}
+
+	if _, err = s.dedupe(moonshot, "GNOG", market.Losers, 60, 11); err != nil {
+		t.Fatalf("Failed with unexpected error for losers: %s", err)
+	}
+}
+
+func TestDedupe_DryRun_DoesNotInsert(t *testing.T) {
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	store := data.NewMemory()
+	s := New(profiles, store)
+	s.DryRun = true
+
+	for i := 0; i < 2; i++ {
+		if _, err = s.dedupe(&profiles[0], "GNOG", market.Gainers, 55, 10); err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+	}
+}
+
+func TestNormalize_MixedCase_ReturnsUpperCase(t *testing.T) {
+	items := []string{"gnog", " GNOG ", "", "Moon"}
+	expected := []string{"GNOG", "MOON"}
+
+	result := unique(normalize(items))
+
+	if !IsEqual(result, expected) {
+		t.Fatalf("Failed expected:%v actual:%v", expected, result)
+	}
+}
+
+func TestDedupe_ReAlertPolicy_ReturnsUpdate(t *testing.T) {
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName, ReAlertGainStep: 50}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	s := New(profiles, data.NewMemory())
+
+	if _, err = s.dedupe(&profiles[0], "GNOG", market.Gainers, 50, 10); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	claim, err := s.dedupe(&profiles[0], "GNOG", market.Gainers, 150, 25)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if !claim.Update || claim.Previous.Percentage != 50 || claim.Previous.Price != 10 {
+		t.Fatalf("Failed with unexpected response: %v", claim)
+	}
+}
+
+func TestScreen_AnalysisErrors_CountsSkippedSymbolsAndProviderErrors(t *testing.T) {
+	original := getAnalysis
+	getAnalysis = func(symbol string) (market.Analysis, error) {
+		if symbol == "GONE" {
+			return market.Analysis{}, fmt.Errorf("%s: %w", symbol, market.ErrSymbolNotFound)
+		}
+		return market.Analysis{}, &market.StatusError{StatusCode: 429, Status: "429 Too Many Requests", Err: market.ErrRateLimited}
+	}
+	defer func() { getAnalysis = original }()
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName, GainThreshold: gainThreshold}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	result := New(profiles, nil).Screen(market.Gainers, []string{"GONE", "GNOG", "MOON"})
+	result.count(errors.New("robinhood: connection reset"))
+
+	if len(result.Rejections) != 3 || result.Rejections[0].Symbol != "GNOG" || result.Rejections[0].Gate != GateAnalysis ||
+		result.Rejections[1].Symbol != "GONE" || result.Rejections[1].Gate != GateNotFound {
+		t.Fatalf("Failed with unexpected rejections: %v", result.Rejections)
+	}
+	if result.SkippedSymbols() != 1 || result.ProviderErrors() != 3 || result.Errors["rateLimited"] != 2 || result.Errors["unavailable"] != 1 {
+		t.Fatalf("Failed with unexpected errors: %v", result.Errors)
+	}
+}
+
+// setNews - Replaces the news lookups, counting the shortened URLs, and returns a func that restores them
+func setNews(companyErr error, shortenErr error, shortened *int) func() {
+	originalCompanyName, originalShortenURL := getCompanyName, shortenURL
+	getCompanyName = func(symbol string) (string, error) {
+		return "GAN Limited", companyErr
+	}
+	shortenURL = func(uri string) (string, error) {
+		*shortened++
+		if shortenErr != nil {
+			return "", shortenErr
+		}
+		return "https://cutt.ly/gnog", nil
+	}
+	return func() { getCompanyName, shortenURL = originalCompanyName, originalShortenURL }
+}
+
+func TestScreen_ShortenerFails_AlertsWithFullNewsURL(t *testing.T) {
+	tc := validateAgainstTresholdsTCs[0]
+	original := getAnalysis
+	getAnalysis = func(symbol string) (market.Analysis, error) {
+		return market.Analysis{Price: tc.price, Rating: tc.rating, FinancialData: tc.financialData}, nil
+	}
+	defer func() { getAnalysis = original }()
+	var shortened int
+	defer setNews(nil, fmt.Errorf("cutt.ly: %w", market.ErrRateLimited), &shortened)()
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName, GainThreshold: gainThreshold}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	result := New(profiles, data.NewMemory()).Screen(market.Gainers, []string{"GNOG"})
+
+	stocks := result.Alerts[screen.DefaultProfileName]
+	if len(stocks) != 1 || stocks[0].NewsURL != "https://news.google.com/search?q=+GAN+" {
+		t.Fatalf("Failed with unexpected alerts: %v %v", stocks, result.Rejections)
+	}
+}
+
+func TestScreen_NewsFails_LeavesNoClaim(t *testing.T) {
+	tc := validateAgainstTresholdsTCs[0]
+	original := getAnalysis
+	getAnalysis = func(symbol string) (market.Analysis, error) {
+		return market.Analysis{Price: tc.price, Rating: tc.rating, FinancialData: tc.financialData}, nil
+	}
+	defer func() { getAnalysis = original }()
+	var shortened int
+	defer setNews(errors.New("yahoo down"), nil, &shortened)()
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName, GainThreshold: gainThreshold}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	store := data.NewMemory()
+
+	result := New(profiles, store).Screen(market.Gainers, []string{"GNOG"})
+
+	if len(result.Alerts[screen.DefaultProfileName]) != 0 || len(result.Rejections) != 1 || result.Rejections[0].Gate != GateNews {
+		t.Fatalf("Failed with unexpected result: %v", result)
+	}
+	if exists, err := store.Exists(data.Key("GNOG", screen.DefaultProfileName, string(market.Gainers))); err != nil || exists {
+		t.Fatalf("Failed with unexpected claim: %t %v", exists, err)
+	}
+	if shortened != 0 {
+		t.Fatalf("Failed with unexpected shortened URLs: %d", shortened)
+	}
+}
+
+func TestScreen_AlreadyClaimed_DoesNotShortenNewsURL(t *testing.T) {
+	tc := validateAgainstTresholdsTCs[0]
+	original := getAnalysis
+	getAnalysis = func(symbol string) (market.Analysis, error) {
+		return market.Analysis{Price: tc.price, Rating: tc.rating, FinancialData: tc.financialData}, nil
+	}
+	defer func() { getAnalysis = original }()
+	var shortened int
+	defer setNews(nil, nil, &shortened)()
+	profiles, err := screen.CompileProfiles([]screen.Profile{{Name: screen.DefaultProfileName, GainThreshold: gainThreshold}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	s := New(profiles, data.NewMemory())
+
+	result := s.Screen(market.Gainers, []string{"GNOG"})
+	if stocks := result.Alerts[screen.DefaultProfileName]; len(stocks) != 1 || stocks[0].NewsURL != "https://cutt.ly/gnog" {
+		t.Fatalf("Failed with unexpected alerts: %v", result.Alerts)
+	}
+
+	result = s.Screen(market.Gainers, []string{"GNOG"})
+	if len(result.Alerts[screen.DefaultProfileName]) != 0 || shortened != 1 {
+		t.Fatalf("Failed with unexpected result after %d shortened URLs: %v", shortened, result)
+	}
+}
+
+func TestBackendFromEnv_DataStore_ResolvesKindAndTarget(t *testing.T) {
+	defer os.Unsetenv("DATA_STORE")
+	defer os.Unsetenv("DATA_STORE_PATH")
+	defer os.Unsetenv("TEST_TABLE_NAME")
+
+	cases := []struct {
+		dataStore, path, tableName string
+		kind, target               string
+	}{
+		{"", "", "", "", ""},
+		{"", "", "alerts", data.KindDynamoDB, "alerts"},
+		{data.KindDynamoDB, "", "alerts", data.KindDynamoDB, "alerts"},
+		{data.KindMemory, "", "alerts", data.KindMemory, ""},
+		{data.KindFile, "/tmp/stonk", "alerts", data.KindFile, "/tmp/stonk.test"},
+	}
+
+	for _, c := range cases {
+		os.Setenv("DATA_STORE", c.dataStore)
+		os.Setenv("DATA_STORE_PATH", c.path)
+		os.Setenv("TEST_TABLE_NAME", c.tableName)
+
+		kind, target := backendFromEnv("TEST_PATH", ".test", "TEST_TABLE_NAME")
+
+		if kind != c.kind || target != c.target {
+			t.Fatalf("Failed with unexpected backend for %+v: %s %s", c, kind, target)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package scan
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+	"github.com/lancehumiston/stonk-lambda/screen"
+)
+
+// followUp - Follow-up event of an alert given the current price, empty when nothing happened
+func followUp(profile *screen.Profile, alertPrice float64, financialData market.FinancialData) string {
+	price := financialData.CurrentPrice.USD
+	switch {
+	case price <= 0:
+		return ""
+	case financialData.TargetHighPrice.USD > 0 && price >= financialData.TargetHighPrice.USD:
+		return notification.AlertTypeTargetHigh
+	case financialData.TargetMeanPrice.USD > 0 && price >= financialData.TargetMeanPrice.USD:
+		return notification.AlertTypeTargetMean
+	case profile.StopLossPercent > 0 && alertPrice > 0 && price <= alertPrice*(1-profile.StopLossPercent/100):
+		return notification.AlertTypeStopLoss
+	}
+
+	return ""
+}
+
+// Watch - Re-checks the stocks alerted since the time and notifies each profile of those that reached a price
+// target or their stop-loss, once per event
+func (s *scanner) Watch(since time.Time) (Result, error) {
+	result := Result{
+		Alerts: make(map[string][]notification.Stock),
+	}
+	if s.Store == nil {
+		return result, fmt.Errorf("watch requires a data store")
+	}
+
+	records, err := s.Store.Recent(since)
+	if err != nil {
+		return result, err
+	}
+
+	// one analysis per symbol regardless of how many profiles alerted it
+	bySymbol := make(map[string][]data.Record)
+	for _, r := range records {
+		if r.Event != "" {
+			continue
+		}
+		if r.Ticker == "" {
+			r.Ticker = r.Symbol // inserted before keys were qualified
+		}
+		bySymbol[r.Ticker] = append(bySymbol[r.Ticker], r)
+	}
+
+	ch := make(chan symbolResult, len(bySymbol))
+	for k, v := range bySymbol {
+		go func(symbol string, records []data.Record, ch chan<- symbolResult) {
+			ch <- s.watchSymbol(symbol, records)
+		}(k, v, ch)
+	}
+
+	for i := 0; i < cap(ch); i++ {
+		r := <-ch
+		result.Rejections = append(result.Rejections, r.rejections...)
+		for _, a := range r.alerts {
+			result.Alerts[a.profile] = append(result.Alerts[a.profile], a.stock)
+		}
+	}
+	for _, r := range result.Rejections {
+		log.Println(r)
+	}
+	for _, stocks := range result.Alerts {
+		sort.SliceStable(stocks, func(i, j int) bool {
+			return stocks[i].Symbol < stocks[j].Symbol
+		})
+	}
+
+	return result, s.Notify(result)
+}
+
+// watchSymbol - Checks the symbol's alerts for follow-up events
+func (s *scanner) watchSymbol(symbol string, records []data.Record) symbolResult {
+	var r symbolResult
+
+	analysis, err := getAnalysis(symbol)
+	if err != nil {
+		r.rejections = append(r.rejections, Rejection{Symbol: symbol, Gate: GateAnalysis, Reason: err.Error()})
+		return r
+	}
+	financialData, rating := analysis.FinancialData, analysis.Rating
+
+	for _, record := range records {
+		profile := s.profile(record.Profile)
+		if profile == nil {
+			continue // profile was removed since the alert
+		}
+
+		alertPrice := record.AlertPrice
+		if alertPrice == 0 {
+			alertPrice = record.Price // inserted before the first alerted price was kept
+		}
+		event := followUp(profile, alertPrice, financialData)
+		if event == "" {
+			continue
+		}
+
+		move := 0.0
+		if alertPrice > 0 {
+			move = (financialData.CurrentPrice.USD/alertPrice - 1) * 100
+		}
+		if err = s.claimFollowUp(record, event, move, financialData.CurrentPrice.USD); err != nil {
+			r.rejections = append(r.rejections, Rejection{Symbol: symbol, Profile: profile.Name, Gate: GateDedupe, Reason: err.Error()})
+			continue
+		}
+
+		r.alerts = append(r.alerts, alert{
+			profile: profile.Name,
+			stock: notification.Stock{
+				Symbol:          symbol,
+				AlertType:       event,
+				Gain:            analysis.Price.MarketChange.Percent,
+				CurrentPrice:    financialData.CurrentPrice.USD,
+				TargetLowPrice:  financialData.TargetLowPrice.USD,
+				TargetHighPrice: financialData.TargetHighPrice.USD,
+				TargetMeanPrice: financialData.TargetMeanPrice.USD,
+				StrongBuy:       rating.StrongBuy,
+				Buy:             rating.Buy,
+				Hold:            rating.Hold,
+				Sell:            rating.Sell,
+				StrongSell:      rating.StrongSell,
+				AlertPrice:      alertPrice,
+				Move:            move,
+			},
+		})
+	}
+
+	return r
+}
+
+// claimFollowUp - Returns an error when the event was already sent for the alert, otherwise claims it. Dry runs only
+// check for an existing record.
+func (s *scanner) claimFollowUp(record data.Record, event string, move float64, price float64) error {
+	key := data.EventKey(record.Symbol, event)
+	if s.DryRun {
+		exists, err := s.Store.Exists(key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("record exists for %s", key)
+		}
+		return nil
+	}
+
+	claim, err := s.Store.Claim(data.Record{
+		Ticker:     record.Ticker,
+		Profile:    record.Profile,
+		Direction:  record.Direction,
+		Event:      event,
+		Percentage: move,
+		Price:      price,
+	}, data.ReAlertPolicy{})
+	if err != nil {
+		return err
+	}
+	if !claim.Won {
+		return fmt.Errorf("record exists for %s", key)
+	}
+
+	return nil
+}
+
+// profile - Returns the profile with the name, records without a profile belong to the default profile
+func (s *scanner) profile(name string) *screen.Profile {
+	if name == "" {
+		name = screen.DefaultProfileName
+	}
+	for i := range s.Profiles {
+		if s.Profiles[i].Name == name {
+			return &s.Profiles[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package scan
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lancehumiston/stonk-lambda/data"
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+	"github.com/lancehumiston/stonk-lambda/screen"
+)
+
+// setAnalysis - Replaces the market data lookup with fixed prices per symbol and returns a func that restores it
+func setAnalysis(prices map[string]float64) func() {
+	original := getAnalysis
+	getAnalysis = func(symbol string) (market.Analysis, error) {
+		price, ok := prices[symbol]
+		if !ok {
+			return market.Analysis{}, fmt.Errorf("%s not found", symbol)
+		}
+
+		var a market.Analysis
+		a.FinancialData.CurrentPrice.USD = price
+		a.FinancialData.TargetMeanPrice.USD = 15
+		a.FinancialData.TargetHighPrice.USD = 20
+		return a, nil
+	}
+	return func() { getAnalysis = original }
+}
+
+func TestFollowUp(t *testing.T) {
+	profile := &screen.Profile{StopLossPercent: 10}
+	tcs := []struct {
+		price    float64
+		expected string
+	}{
+		{21, notification.AlertTypeTargetHigh},
+		{15, notification.AlertTypeTargetMean},
+		{12, ""},
+		{9.01, ""},
+		{9, notification.AlertTypeStopLoss},
+		{0, ""},
+	}
+
+	for _, tc := range tcs {
+		var f market.FinancialData
+		f.CurrentPrice.USD = tc.price
+		f.TargetMeanPrice.USD = 15
+		f.TargetHighPrice.USD = 20
+		if actual := followUp(profile, 10, f); actual != tc.expected {
+			t.Fatalf("Failed for %.2f expected:%s actual:%s", tc.price, tc.expected, actual)
+		}
+	}
+
+	if actual := followUp(&screen.Profile{}, 10, market.FinancialData{CurrentPrice: market.Currency{USD: 1}}); actual != "" {
+		t.Fatalf("Failed to disable stop-loss: %s", actual)
+	}
+}
+
+func TestWatch_AlertedStocks_SendsEachEventOnce(t *testing.T) {
+	profiles, err := screen.CompileProfiles([]screen.Profile{
+		{Name: screen.DefaultProfileName, StopLossPercent: 10},
+		{Name: "moonshot"},
+	})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	store := data.NewMemory()
+	for _, r := range []data.Record{
+		{Ticker: "GNOG", Price: 10},
+		{Ticker: "GNOG", Profile: "moonshot", Price: 16},
+		{Ticker: "DUD", Price: 10},
+		{Ticker: "FLAT", Price: 10},
+		{Ticker: "GONE", Profile: "removed", Price: 10},
+	} {
+		if err = store.Insert(r); err != nil {
+			t.Fatalf("Failed with unexpected error: %s", err)
+		}
+	}
+	// a re-alert raises the last alerted price but the stop-loss stays relative to the first alert
+	if _, err = store.Claim(data.Record{Ticker: "FLAT", Price: 12}, data.ReAlertPolicy{PriceStep: 10}); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer setAnalysis(map[string]float64{"GNOG": 16, "DUD": 8.5, "FLAT": 10, "GONE": 30})()
+	s := New(profiles, store)
+
+	result, err := s.Watch(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	alerts := result.Alerts[screen.DefaultProfileName]
+	if len(alerts) != 2 {
+		t.Fatalf("Failed with unexpected alerts: %v", result.Alerts)
+	}
+	dud, gnog := alerts[0], alerts[1]
+	if dud.Symbol != "DUD" || dud.AlertType != notification.AlertTypeStopLoss || dud.AlertPrice != 10 || fmt.Sprintf("%.2f", dud.Move) != "-15.00" {
+		t.Fatalf("Failed with unexpected stop-loss alert: %+v", dud)
+	}
+	if gnog.Symbol != "GNOG" || gnog.AlertType != notification.AlertTypeTargetMean || fmt.Sprintf("%.2f", gnog.Move) != "60.00" {
+		t.Fatalf("Failed with unexpected target alert: %+v", gnog)
+	}
+	if moonshot := result.Alerts["moonshot"]; len(moonshot) != 1 || moonshot[0].Move != 0 {
+		t.Fatalf("Failed with unexpected moonshot alerts: %v", moonshot)
+	}
+
+	result, err = s.Watch(time.Now().Add(-time.Hour))
+	if err != nil || len(result.Alerts) != 0 {
+		t.Fatalf("Failed to send events once: %v %v", result.Alerts, err)
+	}
+}
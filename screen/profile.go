@@ -0,0 +1,198 @@
+package screen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+)
+
+const (
+	// ModeRules - Symbols must pass every screening rule
+	ModeRules = "rules"
+	// ModeScore - Symbols must reach the profile's minimum composite score
+	ModeScore = "score"
+
+	// DefaultProfileName - Name of the profile built from environment variables when no profile file is configured
+	DefaultProfileName = "default"
+)
+
+// Profile - Named screening configuration with its own thresholds and destination topic
+type Profile struct {
+	Name                   string                 `json:"name"`
+	GainThreshold          float64                `json:"gainThreshold"`
+	DropThreshold          float64                `json:"dropThreshold"`
+	TargetUpsideMultiplier float64                `json:"targetUpsideMultiplier"`
+	SnsTopicArn            string                 `json:"snsTopicArn"`
+	Mode                   string                 `json:"mode"`
+	RulesFile              string                 `json:"rulesFile"`
+	LoserRulesFile         string                 `json:"loserRulesFile"`
+	Weights                *Weights               `json:"weights"`
+	MinScore               *float64               `json:"minScore"`
+	RequireUpgrade         bool                   `json:"requireUpgrade"`
+	ReAlertGainStep        float64                `json:"reAlertGainStep"`  // gain percentage points over the last alert that allows a follow-up
+	ReAlertPriceStep       float64                `json:"reAlertPriceStep"` // percent over the last alerted price that allows a follow-up
+	StopLossPercent        float64                `json:"stopLossPercent"`  // drop below the alert price that sends a follow-up, zero disables
+	Channels               []notification.Channel `json:"channels"`
+
+	rules      Rules
+	loserRules Rules
+	gates      Rules // applied in every mode
+	weights    Weights
+	minScore   float64
+}
+
+// LoadProfiles - Reads and compiles a JSON profile file
+func LoadProfiles(path string) ([]Profile, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p []Profile
+	if err = json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("profile file %s: %w", path, err)
+	}
+
+	return CompileProfiles(p)
+}
+
+// CompileProfiles - Validates the profiles, applies defaults and loads their rules
+func CompileProfiles(profiles []Profile) ([]Profile, error) {
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("at least one profile is required")
+	}
+
+	names := make(map[string]struct{})
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Name == "" {
+			return nil, fmt.Errorf("profile %d has no name", i)
+		}
+		if _, ok := names[p.Name]; ok {
+			return nil, fmt.Errorf("profile %q is defined more than once", p.Name)
+		}
+		names[p.Name] = struct{}{}
+
+		if err := p.compile(); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+
+	return profiles, nil
+}
+
+func (p *Profile) compile() error {
+	if p.Mode == "" {
+		p.Mode = ModeRules
+	}
+	if p.Mode != ModeRules && p.Mode != ModeScore {
+		return fmt.Errorf("mode must be %q or %q", ModeRules, ModeScore)
+	}
+	if p.TargetUpsideMultiplier == 0 {
+		p.TargetUpsideMultiplier = 1.5
+	}
+	p.minScore = 60
+	if p.MinScore != nil {
+		p.minScore = *p.MinScore
+	}
+
+	p.weights = DefaultWeights()
+	if p.Weights != nil {
+		if err := p.Weights.validate(); err != nil {
+			return err
+		}
+		p.weights = *p.Weights
+	}
+
+	if p.DropThreshold == 0 {
+		p.DropThreshold = p.GainThreshold
+	}
+
+	var err error
+	p.rules = DefaultRules(p.GainThreshold, p.TargetUpsideMultiplier)
+	if p.RulesFile != "" {
+		if p.rules, err = Load(p.RulesFile); err != nil {
+			return err
+		}
+	}
+
+	p.loserRules = DefaultLoserRules(p.DropThreshold, p.TargetUpsideMultiplier)
+	if p.LoserRulesFile != "" {
+		if p.loserRules, err = Load(p.LoserRulesFile); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range p.Channels {
+		if _, err = notification.NewNotifier(c); err != nil {
+			return err
+		}
+	}
+
+	if p.RequireUpgrade {
+		p.gates = MustCompile(Rules{UpgradeRule()})
+	}
+
+	return nil
+}
+
+// NotificationChannels - Returns the profile's channels, including the SnsTopicArn topic when configured and
+// not already listed in Channels
+func (p *Profile) NotificationChannels() []notification.Channel {
+	var channels []notification.Channel
+	if p.SnsTopicArn != "" && !p.hasTopic(p.SnsTopicArn) {
+		channels = append(channels, notification.Channel{
+			Type:     notification.ChannelSNS,
+			TopicArn: p.SnsTopicArn,
+		})
+	}
+
+	return append(channels, p.Channels...)
+}
+
+// hasTopic - Determines if the topic is configured as an sns channel
+func (p *Profile) hasTopic(topicArn string) bool {
+	for _, c := range p.Channels {
+		if c.Type == notification.ChannelSNS && c.TopicArn == topicArn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Screen - Scores the subject and verifies it passes the profile's screening mode and gates
+func (p *Profile) Screen(s Subject) (Score, error) {
+	score := p.weights.Score(s)
+
+	if err := p.screenMode(s, score); err != nil {
+		return score, err
+	}
+	if len(p.gates) > 0 {
+		return score, p.gates.Evaluate(s)
+	}
+
+	return score, nil
+}
+
+// screenMode - Verifies the subject passes the profile's rules, or reaches its minimum score in score mode
+func (p *Profile) screenMode(s Subject, score Score) error {
+	if p.Mode == ModeScore {
+		if score.Total < p.minScore {
+			return &RuleError{
+				Rule:   "minScore",
+				Reason: fmt.Sprintf("%s score:%.2f is below minScore:%.2f", s.Symbol, score.Total, p.minScore),
+			}
+		}
+		return nil
+	}
+
+	if s.Direction == market.Losers {
+		return p.loserRules.Evaluate(s)
+	}
+
+	return p.rules.Evaluate(s)
+}
@@ -0,0 +1,269 @@
+package screen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+	"github.com/lancehumiston/stonk-lambda/notification"
+)
+
+func TestLoadProfiles_ProfileFile_ScreensPerProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "profiles.json")
+	body := `[
+		{"name": "moonshot", "gainThreshold": 100, "targetUpsideMultiplier": 2, "snsTopicArn": "arn:moonshot"},
+		{"name": "steady", "gainThreshold": 10, "targetUpsideMultiplier": 1.1, "snsTopicArn": "arn:steady"}
+	]`
+	if err = ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s := Subject{
+		Symbol: "GNOG",
+		Price: market.Price{
+			MarketChange: market.Percent{Percent: 20},
+		},
+		Rating: market.RecommendationRating{Buy: 1},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 12},
+		},
+	}
+
+	if _, err = profiles[0].Screen(s); err == nil || err.Error() != "GNOG gain:20.00 is not above threshold:100.00" {
+		t.Fatalf("Failed with unexpected moonshot response: %v", err)
+	}
+	if _, err = profiles[1].Screen(s); err != nil {
+		t.Fatalf("Failed with unexpected steady error: %s", err)
+	}
+}
+
+func TestCompileProfiles_TargetUpsideMultiplier_ReturnsReason(t *testing.T) {
+	profiles, err := CompileProfiles([]Profile{{Name: "steady", GainThreshold: 10, TargetUpsideMultiplier: 1.2}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s := Subject{
+		Symbol: "GNOG",
+		Price: market.Price{
+			MarketChange: market.Percent{Percent: 20},
+		},
+		Rating: market.RecommendationRating{Buy: 1},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 11},
+		},
+	}
+
+	_, err = profiles[0].Screen(s)
+
+	expected := "GNOG targetMeanPrice:11.00 is less than 20PercentGainPrice:12.00 currentPrice:10.00"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Failed expected: %s, actual: %v", expected, err)
+	}
+}
+
+func TestScreen_ScoreMode_GatesOnMinScore(t *testing.T) {
+	minScore := 90.0
+	profiles, err := CompileProfiles([]Profile{{Name: "scored", Mode: ModeScore, MinScore: &minScore}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	score, err := profiles[0].Screen(Subject{Symbol: "GNOG"})
+
+	if err == nil {
+		t.Fatalf("Failed with unexpected score: %.2f", score.Total)
+	}
+}
+
+func TestLoadProfiles_ZeroMinScore_KeepsZero(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "profiles.json")
+	body := `[
+		{"name": "everything", "mode": "score", "minScore": 0},
+		{"name": "default", "mode": "score"}
+	]`
+	if err = ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if profiles[0].minScore != 0 || profiles[1].minScore != 60 {
+		t.Fatalf("Failed with unexpected minScores: %.2f, %.2f", profiles[0].minScore, profiles[1].minScore)
+	}
+	if _, err = profiles[0].Screen(Subject{Symbol: "GNOG"}); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+}
+
+func TestCompileProfiles_InvalidProfiles_ReturnsError(t *testing.T) {
+	invalid := [][]Profile{
+		nil,
+		{{Name: ""}},
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: "a", Mode: "yolo"}},
+		{{Name: "a", Weights: &Weights{}}},
+		{{Name: "a", Weights: &Weights{Gain: 2, Rating: -1}}},
+		{{Name: "a", Channels: []notification.Channel{{Type: "pager"}}}},
+	}
+
+	for _, p := range invalid {
+		if _, err := CompileProfiles(p); err == nil {
+			t.Fatalf("Failed to reject invalid profiles: %v", p)
+		}
+	}
+}
+
+func TestScreen_Losers_AppliesMirroredRules(t *testing.T) {
+	profiles, err := CompileProfiles([]Profile{{Name: "dip", GainThreshold: 50, DropThreshold: 20}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s := Subject{
+		Symbol:    "GNOG",
+		Direction: market.Losers,
+		Price: market.Price{
+			MarketChange:   market.Percent{Percent: -25},
+			PreMarketPrice: market.Currency{USD: 12},
+		},
+		Rating: market.RecommendationRating{StrongBuy: 2},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 20},
+		},
+	}
+
+	if _, err = profiles[0].Screen(s); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s.Price.MarketChange.Percent = -10
+	_, err = profiles[0].Screen(s)
+
+	expected := "GNOG gain:-10.00 is not below threshold:-20.00"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Failed expected: %s, actual: %v", expected, err)
+	}
+}
+
+func TestScreen_RequireUpgrade_RejectsWithoutNetUpgrades(t *testing.T) {
+	profiles, err := CompileProfiles([]Profile{{Name: "momentum", GainThreshold: 10, RequireUpgrade: true}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s := Subject{
+		Symbol: "GNOG",
+		Price: market.Price{
+			MarketChange: market.Percent{Percent: 20},
+		},
+		Trend: market.Trend{
+			{Period: "0m", Buy: 1},
+			{Period: "-1m", Buy: 2, StrongSell: 1},
+		},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 20},
+		},
+	}
+	s.Rating = s.Trend[0]
+
+	_, err = profiles[0].Screen(s)
+
+	expected := "GNOG has netUpgrades:0 bullishChange:-1 bearishChange:-1"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Failed expected: %s, actual: %v", expected, err)
+	}
+
+	s.Trend[0].StrongBuy = 3
+	if _, err = profiles[0].Screen(s); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+}
+
+func TestScreen_ScoreModeRequireUpgrade_RejectsWithoutNetUpgrades(t *testing.T) {
+	minScore := 1.0
+	profiles, err := CompileProfiles([]Profile{{Name: "scored", Mode: ModeScore, MinScore: &minScore, RequireUpgrade: true}})
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	s := Subject{
+		Symbol: "GNOG",
+		Price: market.Price{
+			MarketChange: market.Percent{Percent: 20},
+		},
+		Trend: market.Trend{
+			{Period: "0m", Buy: 1},
+			{Period: "-1m", Buy: 2, StrongSell: 1},
+		},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 20},
+		},
+	}
+	s.Rating = s.Trend[0]
+
+	score, err := profiles[0].Screen(s)
+
+	expected := "GNOG has netUpgrades:0 bullishChange:-1 bearishChange:-1"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Failed expected: %s, actual: %v (score:%.2f)", expected, err, score.Total)
+	}
+
+	s.Trend[0].StrongBuy = 3
+	if _, err = profiles[0].Screen(s); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+}
+
+func TestNotificationChannels_SnsTopicArn_AddsSnsChannel(t *testing.T) {
+	p := Profile{
+		SnsTopicArn: "arn:aws:sns:us-west-2:123456789012:stonks",
+		Channels:    []notification.Channel{{Type: notification.ChannelSlack, URL: "https://hooks.slack.com/services/x"}},
+	}
+
+	channels := p.NotificationChannels()
+
+	if len(channels) != 2 || channels[0].Type != notification.ChannelSNS || channels[0].TopicArn != p.SnsTopicArn || channels[1].Type != notification.ChannelSlack {
+		t.Fatalf("Failed with unexpected channels: %v", channels)
+	}
+}
+
+func TestNotificationChannels_ConfiguredTopic_NotDuplicated(t *testing.T) {
+	p := Profile{
+		SnsTopicArn: "arn:aws:sns:us-west-2:123456789012:stonks",
+		Channels:    []notification.Channel{{Type: notification.ChannelSNS, TopicArn: "arn:aws:sns:us-west-2:123456789012:stonks", Compact: true}},
+	}
+
+	channels := p.NotificationChannels()
+
+	if len(channels) != 1 || !channels[0].Compact {
+		t.Fatalf("Failed with unexpected channels: %v", channels)
+	}
+}
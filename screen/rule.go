@@ -0,0 +1,368 @@
+package screen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+// Subject - Market data for a single symbol that rules are evaluated against
+type Subject struct {
+	Symbol        string
+	Direction     market.Direction
+	Price         market.Price
+	Rating        market.RecommendationRating
+	Trend         market.Trend
+	FinancialData market.FinancialData
+}
+
+// fields - Rule field names mapped to their Subject accessor
+var fields = map[string]func(s Subject) float64{
+	"gain":            func(s Subject) float64 { return s.Price.MarketChange.Percent },
+	"preMarketPrice":  func(s Subject) float64 { return s.Price.PreMarketPrice.USD },
+	"currentPrice":    func(s Subject) float64 { return s.FinancialData.CurrentPrice.USD },
+	"targetHighPrice": func(s Subject) float64 { return s.FinancialData.TargetHighPrice.USD },
+	"targetLowPrice":  func(s Subject) float64 { return s.FinancialData.TargetLowPrice.USD },
+	"targetMeanPrice": func(s Subject) float64 { return s.FinancialData.TargetMeanPrice.USD },
+	"strongBuy":       func(s Subject) float64 { return float64(s.Rating.StrongBuy) },
+	"buy":             func(s Subject) float64 { return float64(s.Rating.Buy) },
+	"hold":            func(s Subject) float64 { return float64(s.Rating.Hold) },
+	"sell":            func(s Subject) float64 { return float64(s.Rating.Sell) },
+	"strongSell":      func(s Subject) float64 { return float64(s.Rating.StrongSell) },
+	"bullishChange":   func(s Subject) float64 { c, _ := s.Trend.Change(); return float64(c.Bullish) },
+	"bearishChange":   func(s Subject) float64 { c, _ := s.Trend.Change(); return float64(c.Bearish) },
+	"netUpgrades":     func(s Subject) float64 { c, _ := s.Trend.Change(); return float64(c.NetUpgrades()) },
+}
+
+// operators - Rule comparison operators
+var operators = map[string]func(left, right float64) bool{
+	"gt":  func(left, right float64) bool { return left > right },
+	"gte": func(left, right float64) bool { return left >= right },
+	"lt":  func(left, right float64) bool { return left < right },
+	"lte": func(left, right float64) bool { return left <= right },
+	"eq":  func(left, right float64) bool { return left == right },
+	"ne":  func(left, right float64) bool { return left != right },
+}
+
+var reasonFuncs = template.FuncMap{
+	"fixed": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"int":   func(v float64) string { return fmt.Sprintf("%d", int64(v)) },
+}
+
+// Rule - Named predicate over Subject fields. A rule is either a comparison of Field against
+// Value (or Ref*Multiplier), or a combination of other rules through All, Any or Not.
+type Rule struct {
+	Name       string  `json:"name"`
+	Field      string  `json:"field,omitempty"`
+	Op         string  `json:"op,omitempty"`
+	Value      float64 `json:"value,omitempty"`
+	Ref        string  `json:"ref,omitempty"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+	All        []Rule  `json:"all,omitempty"`
+	Any        []Rule  `json:"any,omitempty"`
+	Not        *Rule   `json:"not,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+
+	reason *template.Template
+}
+
+// Rules - Ordered collection of rules that must all pass
+type Rules []Rule
+
+// reasonData - Values available to a rule's Reason template
+type reasonData struct {
+	Symbol string
+	Name   string
+	Field  string
+	Op     string
+	Ref    string
+	Left   float64
+	Right  float64
+	Fields map[string]float64
+}
+
+// Load - Reads and compiles a JSON rule file
+func Load(path string) (Rules, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Rules
+	if err = json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("rule file %s: %w", path, err)
+	}
+
+	return Compile(r)
+}
+
+// Compile - Validates the rules and parses their Reason templates
+func Compile(rules Rules) (Rules, error) {
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+// MustCompile - Compile that panics on invalid rules
+func MustCompile(rules Rules) Rules {
+	r, err := Compile(rules)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+func (r *Rule) compile() error {
+	kinds := 0
+	if r.Field != "" {
+		kinds++
+		if _, ok := fields[r.Field]; !ok {
+			return fmt.Errorf("rule %q has unknown field %q", r.Name, r.Field)
+		}
+		if _, ok := operators[r.Op]; !ok {
+			return fmt.Errorf("rule %q has unknown op %q", r.Name, r.Op)
+		}
+		if _, ok := fields[r.Ref]; r.Ref != "" && !ok {
+			return fmt.Errorf("rule %q has unknown ref %q", r.Name, r.Ref)
+		}
+	}
+	if len(r.All) > 0 {
+		kinds++
+	}
+	if len(r.Any) > 0 {
+		kinds++
+	}
+	if r.Not != nil {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("rule %q must define exactly one of field, all, any or not", r.Name)
+	}
+
+	for i := range r.All {
+		if err := r.All[i].compile(); err != nil {
+			return err
+		}
+	}
+	for i := range r.Any {
+		if err := r.Any[i].compile(); err != nil {
+			return err
+		}
+	}
+	if r.Not != nil {
+		if err := r.Not.compile(); err != nil {
+			return err
+		}
+	}
+
+	if r.Reason == "" {
+		return nil
+	}
+	t, err := template.New(r.Name).Funcs(reasonFuncs).Option("missingkey=error").Parse(r.Reason)
+	if err != nil {
+		return fmt.Errorf("rule %q reason: %w", r.Name, err)
+	}
+	r.reason = t
+
+	return nil
+}
+
+// Evaluate - Returns an error describing the first rule the subject fails, or nil if all rules pass
+func (r Rules) Evaluate(s Subject) error {
+	values := make(map[string]float64, len(fields))
+	for k, f := range fields {
+		values[k] = f(s)
+	}
+
+	for i := range r {
+		if ok, reason := r[i].evaluate(s, values); !ok {
+			return &RuleError{
+				Rule:   r[i].Name,
+				Reason: reason,
+			}
+		}
+	}
+
+	return nil
+}
+
+// RuleError - Failure of a screening rule, the message is the rule's reason
+type RuleError struct {
+	Rule   string // name of the top level rule that failed
+	Reason string
+}
+
+func (e *RuleError) Error() string {
+	return e.Reason
+}
+
+// evaluate - Returns whether the subject passes the rule, and the failure reason when it doesn't
+func (r *Rule) evaluate(s Subject, values map[string]float64) (bool, string) {
+	d := reasonData{
+		Symbol: s.Symbol,
+		Name:   r.Name,
+		Field:  r.Field,
+		Op:     r.Op,
+		Ref:    r.Ref,
+		Fields: values,
+	}
+
+	var ok bool
+	var reason string
+	switch {
+	case r.Field != "":
+		d.Left = values[r.Field]
+		d.Right = r.Value
+		if r.Ref != "" {
+			multiplier := r.Multiplier
+			if multiplier == 0 {
+				multiplier = 1
+			}
+			d.Right = values[r.Ref] * multiplier
+		}
+		ok = operators[r.Op](d.Left, d.Right)
+		reason = r.comparisonReason(d)
+	case len(r.All) > 0:
+		ok = true
+		for i := range r.All {
+			if ok, reason = r.All[i].evaluate(s, values); !ok {
+				break
+			}
+		}
+	case len(r.Any) > 0:
+		var reasons []string
+		for i := range r.Any {
+			var childReason string
+			if ok, childReason = r.Any[i].evaluate(s, values); ok {
+				break
+			}
+			reasons = append(reasons, childReason)
+		}
+		reason = fmt.Sprintf("%s failed %s: %s", s.Symbol, r.Name, strings.Join(reasons, "; "))
+	case r.Not != nil:
+		ok, _ = r.Not.evaluate(s, values)
+		ok = !ok
+		reason = fmt.Sprintf("%s matched %s", s.Symbol, r.Not.Name)
+	}
+
+	if ok || r.reason == nil {
+		return ok, reason
+	}
+
+	var sb strings.Builder
+	if err := r.reason.Execute(&sb, d); err != nil {
+		return ok, fmt.Sprintf("%s (reason template: %s)", reason, err)
+	}
+
+	return ok, sb.String()
+}
+
+// comparisonReason - Default failure reason for a field comparison
+func (r *Rule) comparisonReason(d reasonData) string {
+	right := "threshold"
+	if r.Ref != "" {
+		right = r.Ref
+		if r.Multiplier != 0 && r.Multiplier != 1 {
+			right = fmt.Sprintf("%s*%.2f", r.Ref, r.Multiplier)
+		}
+	}
+
+	return fmt.Sprintf("%s %s:%.2f is not %s %s:%.2f", d.Symbol, r.Field, d.Left, r.Op, right, d.Right)
+}
+
+// DefaultRules - Built-in screen used when no rule file is configured
+func DefaultRules(gainThreshold float64, targetUpsideMultiplier float64) Rules {
+	return MustCompile(Rules{
+		{
+			Name:   "gainThreshold",
+			Field:  "gain",
+			Op:     "gte",
+			Value:  gainThreshold,
+			Reason: "{{.Symbol}} gain:{{fixed .Left}} is not above threshold:{{fixed .Right}}",
+		},
+		{
+			Name:   "preMarketMomentum",
+			Field:  "preMarketPrice",
+			Op:     "lte",
+			Ref:    "currentPrice",
+			Reason: "{{.Symbol}} preMarketPrice:{{fixed .Left}} is above currentPrice:{{fixed .Right}}",
+		},
+		noSellRatingRule(),
+		buyRatingRule(),
+		targetUpsideRule(targetUpsideMultiplier),
+	})
+}
+
+// DefaultLoserRules - Built-in reversal screen for losers used when no rule file is configured
+func DefaultLoserRules(dropThreshold float64, targetUpsideMultiplier float64) Rules {
+	return MustCompile(Rules{
+		{
+			Name:   "dropThreshold",
+			Field:  "gain",
+			Op:     "lte",
+			Value:  -dropThreshold,
+			Reason: "{{.Symbol}} gain:{{fixed .Left}} is not below threshold:{{fixed .Right}}",
+		},
+		noSellRatingRule(),
+		buyRatingRule(),
+		targetUpsideRule(targetUpsideMultiplier),
+	})
+}
+
+// UpgradeRule - Requires analysts to have net upgraded the symbol since the previous month
+func UpgradeRule() Rule {
+	return Rule{
+		Name:   "upgradeMomentum",
+		Field:  "netUpgrades",
+		Op:     "gt",
+		Value:  0,
+		Reason: "{{.Symbol}} has netUpgrades:{{int .Left}} bullishChange:{{int .Fields.bullishChange}} bearishChange:{{int .Fields.bearishChange}}",
+	}
+}
+
+func noSellRatingRule() Rule {
+	return Rule{
+		Name: "noSellRating",
+		All: []Rule{
+			{Name: "noSell", Field: "sell", Op: "eq", Value: 0},
+			{Name: "noStrongSell", Field: "strongSell", Op: "eq", Value: 0},
+		},
+		Reason: "{{.Symbol}} has sell:{{int .Fields.sell}} strongSell:{{int .Fields.strongSell}} rating",
+	}
+}
+
+func buyRatingRule() Rule {
+	return Rule{
+		Name: "buyRating",
+		Any: []Rule{
+			{Name: "buy", Field: "buy", Op: "gt", Value: 0},
+			{Name: "strongBuy", Field: "strongBuy", Op: "gt", Value: 0},
+		},
+		Reason: "{{.Symbol}} has buy:{{int .Fields.buy}} strongBuy:{{int .Fields.strongBuy}} rating",
+	}
+}
+
+func targetUpsideRule(targetUpsideMultiplier float64) Rule {
+	upsideLabel := "fiftyPercentGainPrice"
+	if targetUpsideMultiplier != 1.5 {
+		upsideLabel = fmt.Sprintf("%.0fPercentGainPrice", (targetUpsideMultiplier-1)*100)
+	}
+
+	return Rule{
+		Name:       "targetUpside",
+		Field:      "targetMeanPrice",
+		Op:         "gte",
+		Ref:        "currentPrice",
+		Multiplier: targetUpsideMultiplier,
+		Reason:     "{{.Symbol}} targetMeanPrice:{{fixed .Left}} is less than " + upsideLabel + ":{{fixed .Right}} currentPrice:{{fixed .Fields.currentPrice}}",
+	}
+}
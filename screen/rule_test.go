@@ -0,0 +1,177 @@
+package screen
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+var subject = Subject{
+	Symbol: "GNOG",
+	Price: market.Price{
+		MarketChange: market.Percent{
+			Percent: 60,
+		},
+		PreMarketPrice: market.Currency{
+			USD: 9,
+		},
+	},
+	Rating: market.RecommendationRating{
+		Buy:  2,
+		Hold: 1,
+	},
+	FinancialData: market.FinancialData{
+		CurrentPrice: market.Currency{
+			USD: 10,
+		},
+		TargetMeanPrice: market.Currency{
+			USD: 12,
+		},
+	},
+}
+
+type ruleTestCase struct {
+	name     string
+	rules    Rules
+	expected string
+}
+
+var evaluateTCs = []ruleTestCase{
+	{
+		name:     "Success field gte value",
+		rules:    Rules{{Name: "gain", Field: "gain", Op: "gte", Value: 50}},
+		expected: "<nil>",
+	},
+	{
+		name:     "Fail field gte value default reason",
+		rules:    Rules{{Name: "gain", Field: "gain", Op: "gte", Value: 70}},
+		expected: "GNOG gain:60.00 is not gte threshold:70.00",
+	},
+	{
+		name:     "Fail field gte ref*multiplier default reason",
+		rules:    Rules{{Name: "upside", Field: "targetMeanPrice", Op: "gte", Ref: "currentPrice", Multiplier: 1.5}},
+		expected: "GNOG targetMeanPrice:12.00 is not gte currentPrice*1.50:15.00",
+	},
+	{
+		name: "Fail all stops at first failure",
+		rules: Rules{{Name: "ratings", All: []Rule{
+			{Name: "buy", Field: "buy", Op: "gt", Value: 0},
+			{Name: "noHold", Field: "hold", Op: "eq", Value: 0},
+			{Name: "strongBuy", Field: "strongBuy", Op: "gt", Value: 0},
+		}}},
+		expected: "GNOG hold:1.00 is not eq threshold:0.00",
+	},
+	{
+		name: "Success any with one passing rule",
+		rules: Rules{{Name: "ratings", Any: []Rule{
+			{Name: "strongBuy", Field: "strongBuy", Op: "gt", Value: 0},
+			{Name: "buy", Field: "buy", Op: "gt", Value: 0},
+		}}},
+		expected: "<nil>",
+	},
+	{
+		name: "Fail any with no passing rules",
+		rules: Rules{{Name: "ratings", Any: []Rule{
+			{Name: "strongBuy", Field: "strongBuy", Op: "gt", Value: 0},
+			{Name: "sell", Field: "sell", Op: "gt", Value: 0},
+		}}},
+		expected: "GNOG failed ratings: GNOG strongBuy:0.00 is not gt threshold:0.00; GNOG sell:0.00 is not gt threshold:0.00",
+	},
+	{
+		name:     "Fail not",
+		rules:    Rules{{Name: "notHeld", Not: &Rule{Name: "held", Field: "hold", Op: "gt", Value: 0}}},
+		expected: "GNOG matched held",
+	},
+	{
+		name: "Fail custom reason template",
+		rules: Rules{{
+			Name:   "ratings",
+			Field:  "strongBuy",
+			Op:     "gt",
+			Value:  0,
+			Reason: "{{.Symbol}} has strongBuy:{{int .Left}} buy:{{int .Fields.buy}}",
+		}},
+		expected: "GNOG has strongBuy:0 buy:2",
+	},
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, tc := range evaluateTCs {
+		rules, err := Compile(tc.rules)
+		if err != nil {
+			t.Fatalf("%s failed with unexpected error: %s", tc.name, err)
+		}
+
+		actual := rules.Evaluate(subject)
+
+		if actual == nil && tc.expected != "<nil>" || actual != nil && actual.Error() != tc.expected {
+			t.Fatalf("%s expected: %s, actual: %v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestCompile_InvalidRules_ReturnsError(t *testing.T) {
+	invalid := []Rules{
+		{{Name: "unknownField", Field: "volume", Op: "gt"}},
+		{{Name: "unknownOp", Field: "gain", Op: "between"}},
+		{{Name: "unknownRef", Field: "gain", Op: "gt", Ref: "volume"}},
+		{{Name: "empty"}},
+		{{Name: "ambiguous", Field: "gain", Op: "gt", Not: &Rule{Name: "buy", Field: "buy", Op: "gt"}}},
+		{{Name: "badTemplate", Field: "gain", Op: "gt", Reason: "{{.Symbol"}},
+	}
+
+	for _, rules := range invalid {
+		if _, err := Compile(rules); err == nil {
+			t.Fatalf("Failed to reject invalid rule: %v", rules)
+		}
+	}
+}
+
+func TestLoad_RuleFile_ReturnsRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules.json")
+	body := `[
+		{"name": "gain", "field": "gain", "op": "gte", "value": 50},
+		{"name": "noSell", "not": {"name": "sell", "field": "sell", "op": "gt", "value": 0}},
+		{"name": "upside", "field": "targetMeanPrice", "op": "gte", "ref": "currentPrice", "multiplier": 1.1}
+	]`
+	if err = ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	rules, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+
+	if len(rules) != 3 {
+		t.Fatalf("Failed with unexpected response: %v", rules)
+	}
+
+	if err = rules.Evaluate(subject); err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+}
+
+func TestEvaluate_Failure_ReturnsRuleError(t *testing.T) {
+	rules := MustCompile(Rules{
+		{Name: "positive", Field: "gain", Op: "gt", Value: 0},
+		{Name: "moonshot", Field: "gain", Op: "gt", Value: 1000},
+	})
+
+	err := rules.Evaluate(subject)
+
+	var ruleErr *RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.Rule != "moonshot" {
+		t.Fatalf("Failed with unexpected error: %v", err)
+	}
+}
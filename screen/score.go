@@ -0,0 +1,154 @@
+package screen
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+const (
+	// gainCeiling - Gain percentage that earns the full gain factor
+	gainCeiling float64 = 100
+	// upsideCeiling - Target mean upside (1 = 100% above current price) that earns the full upside factor
+	upsideCeiling float64 = 1
+	// momentumCeiling - Move from pre-market to current price (0.2 = 20%) that earns the full momentum factor
+	momentumCeiling float64 = 0.2
+)
+
+// Weights - Relative weight of each factor in the composite score
+type Weights struct {
+	Gain     float64 `json:"gain"`
+	Rating   float64 `json:"rating"`
+	Upside   float64 `json:"upside"`
+	Momentum float64 `json:"momentum"`
+}
+
+// DefaultWeights - Weights used when none are configured
+func DefaultWeights() Weights {
+	return Weights{
+		Gain:     1,
+		Rating:   1,
+		Upside:   1,
+		Momentum: 1,
+	}
+}
+
+// ParseWeights - Parses JSON weights, e.g. {"gain":1,"rating":2,"upside":2,"momentum":0.5}
+func ParseWeights(s string) (Weights, error) {
+	var w Weights
+	if err := json.Unmarshal([]byte(s), &w); err != nil {
+		return w, err
+	}
+
+	return w, w.validate()
+}
+
+// validate - Rejects negative weights and weights that are all zero
+func (w Weights) validate() error {
+	if w.Gain < 0 || w.Rating < 0 || w.Upside < 0 || w.Momentum < 0 {
+		return errors.New("score weights cannot be negative")
+	}
+	if w.total() == 0 {
+		return errors.New("score weights cannot all be zero")
+	}
+
+	return nil
+}
+
+func (w Weights) total() float64 {
+	return w.Gain + w.Rating + w.Upside + w.Momentum
+}
+
+// Factor - Contribution of a single factor to the composite score
+type Factor struct {
+	Name   string
+	Value  float64 // normalized 0-1
+	Points float64 // weighted share of the 0-100 score
+}
+
+// Score - Composite 0-100 score and its per-factor breakdown
+type Score struct {
+	Total   float64
+	Factors []Factor
+}
+
+// Score - Computes the weighted composite score for the subject
+func (w Weights) Score(s Subject) Score {
+	total := w.total()
+	if total == 0 {
+		return Score{}
+	}
+
+	factors := []struct {
+		name   string
+		weight float64
+		value  float64
+	}{
+		{"gain", w.Gain, gainValue(s)},
+		{"rating", w.Rating, ratingValue(s)},
+		{"upside", w.Upside, upsideValue(s)},
+		{"momentum", w.Momentum, momentumValue(s)},
+	}
+
+	var score Score
+	for _, f := range factors {
+		points := 100 * f.weight / total * f.value
+		score.Total += points
+		score.Factors = append(score.Factors, Factor{
+			Name:   f.name,
+			Value:  f.value,
+			Points: points,
+		})
+	}
+
+	return score
+}
+
+// gainValue - Regular market gain (or drop for losers) relative to gainCeiling
+func gainValue(s Subject) float64 {
+	if s.Direction == market.Losers {
+		return clamp(-s.Price.MarketChange.Percent / gainCeiling)
+	}
+
+	return clamp(s.Price.MarketChange.Percent / gainCeiling)
+}
+
+// ratingValue - Analyst rating mix where all strongBuy is 1 and all strongSell is 0
+func ratingValue(s Subject) float64 {
+	r := s.Rating
+	count := r.StrongBuy + r.Buy + r.Hold + r.Sell + r.StrongSell
+	if count == 0 {
+		return 0
+	}
+
+	weighted := float64(r.StrongBuy)*1 + float64(r.Buy)*0.75 + float64(r.Hold)*0.5 + float64(r.Sell)*0.25
+	return clamp(weighted / float64(count))
+}
+
+// upsideValue - Target mean price upside relative to upsideCeiling
+func upsideValue(s Subject) float64 {
+	current := s.FinancialData.CurrentPrice.USD
+	if current <= 0 {
+		return 0
+	}
+
+	upside := s.FinancialData.TargetMeanPrice.USD/current - 1
+	return clamp(upside / upsideCeiling)
+}
+
+// momentumValue - Move from pre-market to current price where 0.5 is flat and no pre-market data
+func momentumValue(s Subject) float64 {
+	preMarket := s.Price.PreMarketPrice.USD
+	if preMarket <= 0 {
+		return 0.5
+	}
+
+	change := (s.FinancialData.CurrentPrice.USD - preMarket) / preMarket
+	return clamp(0.5 + change/(2*momentumCeiling))
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
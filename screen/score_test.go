@@ -0,0 +1,86 @@
+package screen
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+func TestScore_StrongCandidate_ScoresAboveWeakCandidate(t *testing.T) {
+	strong := Subject{
+		Symbol: "GNOG",
+		Price: market.Price{
+			MarketChange:   market.Percent{Percent: 49.9},
+			PreMarketPrice: market.Currency{USD: 9},
+		},
+		Rating: market.RecommendationRating{StrongBuy: 3, Buy: 1},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 30},
+		},
+	}
+	weak := Subject{
+		Symbol: "JUNK",
+		Price: market.Price{
+			MarketChange:   market.Percent{Percent: 55},
+			PreMarketPrice: market.Currency{USD: 12},
+		},
+		Rating: market.RecommendationRating{Hold: 1, Sell: 2},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 8},
+		},
+	}
+
+	w := DefaultWeights()
+	strongScore := w.Score(strong)
+	weakScore := w.Score(weak)
+
+	if strongScore.Total <= weakScore.Total {
+		t.Fatalf("Failed expected strong:%.2f to be above weak:%.2f", strongScore.Total, weakScore.Total)
+	}
+}
+
+func TestScore_Factors_SumToTotal(t *testing.T) {
+	s := Subject{
+		Price: market.Price{
+			MarketChange: market.Percent{Percent: 100},
+		},
+		Rating: market.RecommendationRating{StrongBuy: 1},
+		FinancialData: market.FinancialData{
+			CurrentPrice:    market.Currency{USD: 10},
+			TargetMeanPrice: market.Currency{USD: 20},
+		},
+	}
+	w := Weights{Gain: 2, Rating: 1, Upside: 1, Momentum: 0}
+
+	score := w.Score(s)
+
+	var sum float64
+	for _, f := range score.Factors {
+		sum += f.Points
+	}
+	if math.Abs(sum-score.Total) > 0.0001 {
+		t.Fatalf("Failed expected factors:%.2f to sum to total:%.2f", sum, score.Total)
+	}
+	if math.Abs(score.Total-100) > 0.0001 {
+		t.Fatalf("Failed expected maximum score, actual:%.2f %v", score.Total, score.Factors)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights(`{"gain":1,"rating":2,"upside":3,"momentum":0.5}`)
+	if err != nil {
+		t.Fatalf("Failed with unexpected error: %s", err)
+	}
+	if w.Upside != 3 || w.Momentum != 0.5 {
+		t.Fatalf("Failed with unexpected response: %v", w)
+	}
+
+	for _, invalid := range []string{`{}`, `{"gain":-1,"rating":2}`, `not json`} {
+		if _, err = ParseWeights(invalid); err == nil {
+			t.Fatalf("Failed to reject invalid weights: %s", invalid)
+		}
+	}
+}
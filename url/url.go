@@ -1,45 +1,100 @@
 package url
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+
+	"github.com/lancehumiston/stonk-lambda/market"
 )
 
+// CuttlyURL - Base URL of the cutt.ly api
+const CuttlyURL = "https://cutt.ly"
+
 var (
-	apiKey string
+	defaultShortener *shortener
 )
 
 func init() {
-	apiKey = os.Getenv("CUTTLY_API_KEY")
+	limit, err := strconv.Atoi(os.Getenv("CUTTLY_MONTHLY_LIMIT"))
+	if err != nil {
+		limit = 0 // rotate only when cutt.ly reports the limit reached
+	}
+	keys := market.NewKeyPool("cuttly", market.KeysFromEnv("CUTTLY_API_KEYS", "CUTTLY_API_KEY"), limit, market.QuotaMonthly)
+	defaultShortener = NewShortener(market.NewUpstream(CuttlyURL), keys)
+}
+
+type shortener struct {
+	market.Upstream
+	Keys *market.KeyPool
+}
+
+// NewShortener - Returns the cutt.ly client calling the upstream with the pool's keys
+func NewShortener(u market.Upstream, keys *market.KeyPool) *shortener {
+	return &shortener{
+		Upstream: u,
+		Keys:     keys,
+	}
 }
 
 type urlResponse struct {
 	URL struct {
+		Status    int    `json:"status"`
 		ShortLink string `json:"shortLink"`
 	} `json:"url"`
 }
 
+// cutt.ly statuses reported in the response body
+const (
+	statusInvalidKey   = 4
+	statusOK           = 7
+	statusLimitReached = 8
+)
+
 // GetShortenedAlias - Returns a shortened uri alias
 func GetShortenedAlias(uri string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://cutt.ly/api/api.php?key=%s&short=%s", apiKey, url.QueryEscape(uri)))
+	return defaultShortener.GetShortenedAlias(uri)
+}
+
+// GetShortenedAlias - Returns a shortened uri alias, retried with the next key in the pool when cutt.ly rejects a key
+func (s *shortener) GetShortenedAlias(uri string) (string, error) {
+	var alias string
+	err := s.Keys.Do(func(key string) error {
+		var err error
+		alias, err = s.getShortenedAlias(uri, key)
+		return err
+	})
+
+	return alias, err
+}
+
+// getShortenedAlias - Returns a shortened uri alias using the api key, a *market.KeyError wrapping
+// market.ErrUnauthorized or market.ErrRateLimited when cutt.ly rejects the key
+func (s *shortener) getShortenedAlias(uri string, key string) (string, error) {
+	resp, body, err := s.Get(fmt.Sprintf("/api/api.php?key=%s&short=%s", key, url.QueryEscape(uri)))
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	if err = market.CheckStatus(resp); err != nil {
 		return "", err
 	}
 
 	var u urlResponse
-	json.Unmarshal(body, &u)
+	if err = market.DecodeJSON(body, &u); err != nil {
+		return "", fmt.Errorf("cutt.ly: %w", err)
+	}
 	log.Println(u)
 
-	return u.URL.ShortLink, nil
+	switch u.URL.Status {
+	case statusOK:
+		return u.URL.ShortLink, nil
+	case statusInvalidKey:
+		return "", &market.KeyError{Err: fmt.Errorf("cutt.ly: %w", market.ErrUnauthorized)}
+	case statusLimitReached:
+		return "", &market.KeyError{Err: fmt.Errorf("cutt.ly: %w", market.ErrRateLimited)}
+	}
+
+	return "", fmt.Errorf("cutt.ly: status %d shortening %s", u.URL.Status, uri)
 }
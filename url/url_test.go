@@ -1,15 +1,43 @@
 package url
 
 import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/lancehumiston/stonk-lambda/market"
 )
 
+// cuttlyServer - Replays the recorded cutt.ly response for every request
+func cuttlyServer(t *testing.T, file string) (*shortener, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/api.php" || r.URL.Query().Get("key") != "key" || r.URL.Query().Get("short") != "https://google.com" {
+			t.Errorf("Failed with unexpected request: %s", r.URL.RequestURI())
+		}
+		body, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Errorf("Failed with unexpected error: %s", err)
+		}
+		w.Write(body)
+	}))
+
+	keys := market.NewKeyPool("cuttly", []string{"key"}, 0, market.QuotaMonthly)
+
+	return NewShortener(market.Upstream{Client: server.Client(), BaseURL: server.URL}, keys), server.Close
+}
+
 func TestGetShortenedAlias_Success_ReturnsShortenedUrlAlias(t *testing.T) {
+	s, done := cuttlyServer(t, "cuttly_shorten.json")
+	defer done()
+
 	url := "https://google.com"
 	expectedPrefix := "https://cutt.ly/"
 
-	result, err := GetShortenedAlias(url)
+	result, err := s.GetShortenedAlias(url)
 
 	if err != nil {
 		t.Fatalf("Failed with unexpected error: %s", err)
@@ -19,3 +47,43 @@ func TestGetShortenedAlias_Success_ReturnsShortenedUrlAlias(t *testing.T) {
 		t.Fatalf("Failed with unexpected response:%s", result)
 	}
 }
+
+func TestGetShortenedAlias_InvalidKey_ReturnsErrUnauthorized(t *testing.T) {
+	s, done := cuttlyServer(t, "cuttly_invalid_key.json")
+	defer done()
+
+	result, err := s.GetShortenedAlias("https://google.com")
+
+	if !errors.Is(err, market.ErrUnauthorized) || result != "" {
+		t.Fatalf("Failed with unexpected response: %s %v", result, err)
+	}
+}
+
+func TestGetShortenedAlias_TooManyRequests_KeepsKey(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		body, err := ioutil.ReadFile(filepath.Join("testdata", "cuttly_shorten.json"))
+		if err != nil {
+			t.Errorf("Failed with unexpected error: %s", err)
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+	keys := market.NewKeyPool("cuttly", []string{"key"}, 0, market.QuotaMonthly)
+	s := NewShortener(market.Upstream{Client: server.Client(), BaseURL: server.URL}, keys)
+
+	if _, err := s.GetShortenedAlias("https://google.com"); !errors.Is(err, market.ErrRateLimited) {
+		t.Fatalf("Failed with unexpected error: %v", err)
+	}
+
+	// a brief 429 doesn't exhaust the key for the rest of the month
+	result, err := s.GetShortenedAlias("https://google.com")
+	if err != nil || !strings.HasPrefix(result, "https://cutt.ly/") {
+		t.Fatalf("Failed with unexpected response: %s %v", result, err)
+	}
+}
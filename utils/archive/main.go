@@ -25,13 +25,17 @@ func init() {
 }
 
 // insertArchive - Inserts a record for the stock into the long-lived archive data store
-func insertArchive(symbol string, price float64) error {
+func insertArchive(symbol string, profile string, direction string, price float64) error {
 	item := struct {
 		Symbol       string
+		Profile      string `dynamodbav:",omitempty"`
+		Direction    string `dynamodbav:",omitempty"`
 		Price        float64
 		CreatedAtUtc int64
 	}{
 		Symbol:       symbol,
+		Profile:      profile,
+		Direction:    direction,
 		Price:        price,
 		CreatedAtUtc: time.Now().UTC().Unix(),
 	}
@@ -71,7 +75,24 @@ func lambdaHandler(ctx context.Context, record events.DynamoDBEvent) error {
 			log.Printf("%v did not contain valid 'Symbol'", i)
 			continue
 		}
+		if _, ok := i["Event"]; ok {
+			continue // follow-ups of an archived alert, e.g. a price target hit
+		}
+
 		symbol := s.String()
+		if t, ok := i["Ticker"]; ok {
+			symbol = t.String() // Symbol is qualified by the screening profile and direction
+		}
+
+		var profile string
+		if p, ok := i["Profile"]; ok {
+			profile = p.String()
+		}
+
+		var direction string
+		if d, ok := i["Direction"]; ok {
+			direction = d.String()
+		}
 
 		p, ok := i["Price"]
 		if !ok {
@@ -84,7 +105,7 @@ func lambdaHandler(ctx context.Context, record events.DynamoDBEvent) error {
 			continue
 		}
 
-		if err = insertArchive(symbol, price); err != nil {
+		if err = insertArchive(symbol, profile, direction, price); err != nil {
 			log.Print(err) // log and continue to process batch
 		}
 	}
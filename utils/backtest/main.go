@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lancehumiston/stonk-lambda/backtest"
+	"github.com/lancehumiston/stonk-lambda/market"
+)
+
+func main() {
+	alertsFile := flag.String("alerts", "", "archived alerts file")
+	format := flag.String("format", backtest.FormatDynamoDB, "alerts file format: dynamodb, json or csv")
+	historyFile := flag.String("history", "", "Symbol,Date,Close csv of daily closes, defaults to Yahoo's chart API")
+	horizons := flag.String("horizons", "1,5,20", "comma separated trading days to measure returns at")
+	flag.Parse()
+
+	if *alertsFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	days, err := parseHorizons(*horizons)
+	if err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*alertsFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	alerts, err := backtest.ReadAlerts(f, *format)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	history := market.NewYahooPriceHistory()
+	if *historyFile != "" {
+		h, err := os.Open(*historyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		history, err = backtest.NewCSVPriceHistory(h)
+		h.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	results, err := backtest.Run(alerts, history, days)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printResults(results)
+}
+
+func parseHorizons(s string) ([]int, error) {
+	var days []int
+	for _, v := range strings.Split(s, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || d < 1 {
+			return nil, fmt.Errorf("invalid horizon %q, must be a positive number of trading days", v)
+		}
+		days = append(days, d)
+	}
+
+	return days, nil
+}
+
+// printResults - Writes a table with one row per configuration and horizon
+func printResults(results []backtest.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tDIRECTION\tALERTS\tSKIPPED\tDAYS\tMEASURED\tHIT RATE\tAVG RETURN\tMAX DRAWDOWN")
+	for _, r := range results {
+		for _, h := range r.Horizons {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%.1f%%\t%.2f%%\t%.2f%%\n",
+				r.Profile,
+				r.Direction,
+				r.Alerts,
+				r.Skipped,
+				h.Days,
+				h.Count,
+				h.HitRate*100,
+				h.AvgReturn*100,
+				r.MaxDrawdown*100)
+		}
+	}
+	w.Flush()
+}